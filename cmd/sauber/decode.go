@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	internal "github.com/miguno/sauber/internal/pkg"
+)
+
+// decode implements `sauber --decode <name>...`, which prints the original
+// names of names that `sauber --reversible` encoded.  The names may be paths,
+// whose parts are decoded one by one.
+func decode(names []string) int {
+	if len(names) == 0 {
+		_, _ = fmt.Fprintln(os.Stderr, "--decode needs at least one name to decode")
+		return 1
+	}
+	status := 0
+	for _, name := range names {
+		parts := strings.Split(name, "/")
+		var err error
+		for i := range parts {
+			if parts[i], err = internal.DecodeName(parts[i]); err != nil {
+				break
+			}
+		}
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "failed to decode '%s', because %s\n", name, err.Error())
+			status = 1
+			continue
+		}
+		fmt.Println(strings.Join(parts, "/"))
+	}
+	return status
+}
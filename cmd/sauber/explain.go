@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	internal "github.com/miguno/sauber/internal/pkg"
+)
+
+// explain implements `sauber --explain [OPTIONS] <name>`, which prints every
+// stage of sanitizing the name with the given options.  Names that end in a
+// slash are sanitized as directories.  If the name is an existing path,
+// whether it is a directory and which names are taken is read from disk.
+func explain(path string, config internal.Config) int {
+	isDir := strings.HasSuffix(path, "/") || strings.HasSuffix(path, string(filepath.Separator))
+	path = strings.TrimRight(path, "/"+string(filepath.Separator))
+	if path == "" {
+		path = string(filepath.Separator)
+	}
+	name := filepath.Base(path)
+	var taken func(string) bool
+	if info, err := os.Lstat(path); err == nil {
+		isDir = info.IsDir()
+		siblings, err := os.ReadDir(filepath.Dir(path))
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "failed to list contents of '%s', because %s\n", filepath.Dir(path), err.Error())
+			return 1
+		}
+		taken = func(candidate string) bool {
+			for _, sibling := range siblings {
+				if sibling.Name() == name {
+					continue
+				}
+				if sibling.Name() == candidate || config.CaseInsensitive && strings.EqualFold(sibling.Name(), candidate) {
+					return true
+				}
+			}
+			return false
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		_, _ = fmt.Fprintf(os.Stderr, "failed to access '%s', because %s\n", path, err.Error())
+		return 1
+	}
+	stages, err := internal.ExplainName(name, isDir, config, taken)
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	fmt.Printf("%q\n", name)
+	for _, stage := range stages {
+		if stage.Before == stage.After && len(stage.Kept) == 0 {
+			fmt.Printf("  %s: unchanged\n", stage.Name)
+			continue
+		}
+		fmt.Printf("  %s: %q\n", stage.Name, stage.After)
+		for _, change := range stage.Changes {
+			fmt.Printf("    %q => %q\n", change.Before, change.After)
+			for _, c := range change.Before {
+				fmt.Printf("      %s\n", internal.DescribeRune(c))
+			}
+			// Describe the new characters if they are not obvious from the
+			// output, like combining marks.
+			if strings.IndexFunc(change.After, isNotPrintableASCII) >= 0 {
+				for _, c := range change.After {
+					fmt.Printf("      => %s\n", internal.DescribeRune(c))
+				}
+			}
+		}
+		for _, c := range stage.Kept {
+			fmt.Printf("    kept %q because of the allowlist\n", c)
+		}
+	}
+	result := name
+	if len(stages) > 0 {
+		result = stages[len(stages)-1].After
+	}
+	fmt.Printf("=> %q\n", result)
+	return 0
+}
+
+func isNotPrintableASCII(r rune) bool {
+	return r < ' ' || r > '~'
+}
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/jessevdk/go-flags"
 
@@ -20,46 +23,255 @@ func main() {
 		Folder string `description:"Path to process, including any sub-folders and files if path is a folder. (Additional positional arguments are ignored.)" positional-arg-name:"<path>"`
 	}
 	var Options struct {
-		DryRun            bool `short:"d" long:"dry-run" description:"Only show what would be done (default mode)"`
-		ActualRun         bool `short:"f" long:"force" description:"Make actual changes to filesystem ***modifies your data***"`
-		MaxRenameAttempts int  `short:"n" long:"max-rename-attempts" default:"100000" description:"Maximum number of rename attempts per file/folder. sauber will terminate when it can not find a sanitized name after this many attempts."`
-		Silent            bool `short:"s" long:"silent" description:"Suppress output when sanitizing (ignored when dry-running)"`
-		Truncate          int  `short:"t" long:"truncate" default:"999999999" description:"Max number of characters (actually: bytes) in the sanitized name of a file/folder. Any additional characters are truncated, though file extensions are preserved. Note: Encrypted drives on Synology NAS devices have a limit of 143 characters per file/folder (limit applies to basename, not full path). For details see the Synology DSM Tech Specs or view the summary at https://github.com/miguno/sauber/."`
-		Version           bool `short:"v" long:"version" description:"Print version information and exit"`
+		DryRun            bool     `short:"d" long:"dry-run" description:"Only show what would be done (default mode)"`
+		ActualRun         bool     `short:"f" long:"force" description:"Make actual changes to filesystem ***modifies your data***"`
+		Profile           string   `short:"p" long:"profile" value-name:"<name>" description:"Sanitize for the given target system(s): smb, synology-encrypted, windows, macos, fat32, exfat, posix. Stack several profiles with '+', e.g. 'smb+fat32'. Without a profile, sauber applies its default rules without a length limit."`
+		TrimMode          string   `long:"trim-mode" choice:"trim" choice:"replace" default:"trim" description:"How to handle leading spaces as well as trailing dots and spaces, which SMB clients strip or refuse: 'trim' removes them, 'replace' replaces them with '_'"`
+		Locale            []string `long:"locale" value-name:"[<path>=]<locale>" description:"Expand umlauts and similar letters, and write symbols like '€', according to this locale: 'de' (German, default), 'da' (Danish), 'no' (Norwegian), 'sv' (Swedish), 'fi' (Finnish), or 'tr' (Turkish). Prefix the locale with a folder, e.g. '/volume1/music/svenska=sv', to use it for that folder only. Can be repeated."`
+		FromCharset       string   `long:"from-charset" value-name:"<charset>" default:"auto" description:"Decode names that are not valid UTF-8 from this legacy encoding: 'cp1252' (Windows-1252), 'cp850' and 'cp437' (MS-DOS), 'shift-jis', or 'latin1' (ISO 8859-1). 'auto' detects the encoding of each name, 'none' sanitizes such names as they are. Names that cannot be decoded are reported and left alone."`
+		Cyrillic          string   `long:"cyrillic" value-name:"<standard>" default:"bgn" description:"Transliterate Cyrillic according to this standard: 'bgn' (BGN/PCGN), 'iso9' (ISO 9, GOST 7.79 System A), 'gost' (GOST 7.79 System B), or 'scientific'"`
+		CyrillicLanguage  string   `long:"cyrillic-language" value-name:"<language>" default:"ru" description:"Language of Cyrillic names, for standards that transliterate languages differently: 'ru' (Russian), 'uk' (Ukrainian), 'bg' (Bulgarian), or 'sr' (Serbian)"`
+		CJK               string   `long:"cjk" value-name:"<language>" description:"Romanize Chinese, Japanese, and Korean names: Hangul, kana, and the Han characters and words that a built-in dictionary of the given language covers: 'zh' (Chinese, from Unihan), 'ja' (Japanese, from IPADIC), or 'ko' (Korean, keeps Han characters). Other Han characters are kept."`
+		Scripts           string   `long:"scripts" value-name:"<list>" default:"all" description:"Transliterate the given comma-separated scripts: 'arabic', 'devanagari', 'hebrew', and 'thai', or 'all' or 'none'"`
+		Emoji             string   `long:"emoji" value-name:"<mode>" description:"Replace emoji, including sequences like flags and emoji with skin tones: 'name' replaces them with their CLDR short names (e.g., 'beach-with-umbrella'), 'drop' removes them"`
+		Separators        string   `long:"separators" value-name:"<policy>" description:"Rewrite spaces and separators according to this comma-separated policy: 'collapse' collapses runs of spaces and of the same separator, 'mixed' collapses mixed runs like '-_-' or ' - ', 'extension' removes separators before file extensions, and 'spaces=_', 'spaces=-', or 'spaces=.' replaces spaces, e.g. 'collapse,mixed,extension,spaces=_'"`
+		Case              string   `long:"case" value-name:"<style>" description:"Write names in this style: 'lower', 'upper', 'title', 'snake' (my_song.mp3), or 'kebab' (my-song.mp3). File extensions are kept intact. Names that differ in case only count as colliding."`
+		Reversible        bool     `long:"reversible" description:"Encode the characters that the profile does not allow reversibly rather than transliterate them, e.g. 'Müller' as 'M%C3%BCller%_'. Use --decode to print the original names."`
+		Normalization     string   `long:"normalization" value-name:"<form>" description:"Write names in this Unicode normalization form: 'nfc' (e.g., 'é' as U+00E9, as Windows and Linux clients write it) or 'nfd' (e.g., 'é' as 'e' followed by U+0301, as macOS clients write it). Names that look the same but are in different forms are different names to most file systems. Default: nfc."`
+		NormalizeOnly     bool     `long:"normalize-only" description:"Only write names in the normalization form of --normalization, without applying any other sanitize rule"`
+		StrictASCII       string   `long:"strict-ascii" value-name:"<mode>" description:"Guarantee that names consist of the printable ASCII characters that the profile allows: 'unicode' writes any other character by its code point (e.g., 'U+4E2D'), 'hex' writes it in hex (e.g., 'x4e2d'), and 'fail' makes sauber fail instead"`
+		Keep              []string `long:"keep" value-name:"<chars>" description:"Keep these characters, e.g. 'äöüÄÖÜß', which all sanitize rules then leave alone. Can be repeated."`
+		KeepCategories    []string `long:"keep-category" value-name:"<category>" description:"Keep the characters of this Unicode category, e.g. 'L' (letters) or 'Lu' (uppercase letters). Can be repeated."`
+		KeepScripts       []string `long:"keep-script" value-name:"<script>" description:"Keep the characters of this Unicode script, e.g. 'Han' or 'Cyrillic'. Can be repeated."`
+		MojibakeThreshold float64  `long:"mojibake-threshold" value-name:"<confidence>" default:"0.8" description:"Repair names whose UTF-8 was decoded as Latin-1 or CP1252, like 'RÃ¤tsel', if the confidence of the repair is at least this value between 0 and 1"`
+		DisableRules      []string `long:"disable-rule" value-name:"<name>" description:"Do not apply the sanitize rule of this name, e.g. 'normalize-compatibility' (see --list-rules). Can be repeated."`
+		Explain           bool     `long:"explain" description:"Print how each sanitize rule changes the name of the given path, which need not exist, character by character, then exit without renaming anything"`
+		Decode            bool     `long:"decode" description:"Print the original names of the given names (or paths) that --reversible encoded, then exit"`
+		ListRules         bool     `short:"l" long:"list-rules" description:"Print the active sanitize rules in the order in which they are applied, then exit"`
+		RulesFile         string   `short:"r" long:"rules" value-name:"<file>" description:"Load additional mappings and regex rules from a TOML or JSON rules file (see README)"`
+		MaxRenameAttempts int      `short:"n" long:"max-rename-attempts" default:"100000" description:"Maximum number of rename attempts per file/folder. sauber will terminate when it can not find a sanitized name after this many attempts."`
+		Silent            bool     `short:"s" long:"silent" description:"Suppress output when sanitizing (ignored when dry-running)"`
+		Truncate          int      `short:"t" long:"truncate" default:"999999999" description:"Max number of characters (actually: bytes) in the sanitized name of a file/folder. Any additional characters are truncated, though file extensions are preserved. Note: Encrypted drives on Synology NAS devices have a limit of 143 characters per file/folder (limit applies to basename, not full path). For details see the Synology DSM Tech Specs or view the summary at https://github.com/miguno/sauber/."`
+		Version           bool     `short:"v" long:"version" description:"Print version information and exit"`
 		//Folder            string `required:"1" positional-args:"yes" positional-arg-name:"folder" value-name:"foo"`
 		Args OptionsArgs `positional-args:"yes"`
 	}
 	parser := flags.NewParser(&Options, flags.Default)
-	// The `args` return value is ignored because the only positional argument
-	// we accept is already parsed into `OptionsArgs.Folder` automatically
-	// (think: `OptionsArgs.Folder = popd(args)`, thus reducing the args count
-	// by 1).
-	_, err := parser.Parse()
+	// The only positional argument that we accept for processing is already
+	// parsed into `OptionsArgs.Folder` automatically (think:
+	// `OptionsArgs.Folder = popd(args)`, thus reducing the args count by 1).
+	// The remaining args are only used by --decode, which accepts several
+	// names.
+	rest, err := parser.ParseArgs(os.Args[1:])
+	var flagsErr *flags.Error
+	isHelp := errors.As(err, &flagsErr) && flagsErr.Type == flags.ErrHelp
+	if isHelp {
+		usage(parser)
+	}
+	if err != nil {
+		// go-flags has printed the error already.
+		os.Exit(1)
+	}
 
 	if Options.Version {
 		_, _ = fmt.Fprintf(os.Stderr, "sauber version: %s\n", Version)
 		os.Exit(0)
 	}
-	if err == flags.ErrHelp || (Options.Args.Folder == "") {
-		parser.WriteHelp(os.Stderr)
-		s := `
-sauber sanitizes the names of files and directories by replacing umlauts,
-accents, and similar diacritics.  By default, it performs a dry run to
-let you verify any changes it would make.
-
-Examples:
-  # Perform a dry run of sanitizing /volume1/music (including) and all
-  # its sub-directories and files.  This operation does not modify
-  # any data, it only reports what sauber *would* change.
-  $ sauber /volume1/music
-
-  # Sanitize /volume1/music (including) and all its sub-directories and files.
-  # *** WARNING: This command modifies your data! Always do a dry run first! ***
-  $ sauber --force /volume1/music
-
-Suggestions? Bugs? Questions? Go to https://github.com/miguno/sauber/`
-		_, _ = fmt.Fprintln(os.Stderr, s)
-		os.Exit(1)
+	if Options.Decode {
+		var names []string
+		if Options.Args.Folder != "" {
+			names = append([]string{Options.Args.Folder}, rest...)
+		}
+		os.Exit(decode(names))
+	}
+	config := internal.Config{
+		SkipDirectories:          internal.DefaultSkipDirectories,
+		MaxRenameAttemptsPerPath: Options.MaxRenameAttempts,
+		MaxBasenameLength:        Options.Truncate,
+		SilentMode:               Options.Silent,
+		Sanitizer:                internal.NewSanitizer(internal.DefaultRules()...),
+	}
+	var profile internal.Profile
+	if Options.Profile != "" {
+		profile, err = internal.LookupProfile(Options.Profile)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		config.ApplyProfile(profile)
+	}
+	if Options.TrimMode == "replace" {
+		if err := config.Sanitizer.Replace("trim-dots-and-spaces", internal.NewTrimRule("_")); err != nil {
+			log.Fatalf("failed to apply --trim-mode, because %s", reason(err, Options.Profile))
+		}
+	}
+	var subtreeLocales [][2]string
+	for _, locale := range Options.Locale {
+		if path, name, ok := strings.Cut(locale, "="); ok {
+			subtreeLocales = append(subtreeLocales, [2]string{path, name})
+			continue
+		}
+		if err := applyLocale(config.Sanitizer, locale); err != nil {
+			log.Fatalf("failed to apply --locale, because %s", reason(err, Options.Profile))
+		}
+	}
+	if Options.FromCharset != "none" {
+		config.Decoder, err = internal.NewNameDecoder(Options.FromCharset)
+		if err != nil {
+			log.Fatalf("failed to apply --from-charset, because %s", reason(err, Options.Profile))
+		}
+	}
+	if Options.MojibakeThreshold != internal.DefaultMojibakeThreshold {
+		rule, err := internal.NewMojibakeRule(Options.MojibakeThreshold)
+		if err == nil {
+			err = config.Sanitizer.Replace("repair-mojibake", rule)
+		}
+		if err != nil {
+			log.Fatalf("failed to apply --mojibake-threshold, because %s", reason(err, Options.Profile))
+		}
+	}
+	if Options.Cyrillic != "bgn" || Options.CyrillicLanguage != "ru" {
+		rule, err := internal.NewCyrillicRule(Options.Cyrillic, Options.CyrillicLanguage)
+		if err == nil {
+			err = config.Sanitizer.Replace("transliterate-cyrillic", rule)
+		}
+		if err != nil {
+			log.Fatalf("failed to apply --cyrillic, because %s", reason(err, Options.Profile))
+		}
+	}
+	if Options.Scripts != "all" {
+		var scripts []string
+		if Options.Scripts != "none" {
+			scripts = strings.Split(Options.Scripts, ",")
+		}
+		rule, err := internal.NewScriptRule(scripts...)
+		if err == nil {
+			err = config.Sanitizer.Replace("transliterate-scripts", rule)
+		}
+		if err != nil {
+			log.Fatalf("failed to apply --scripts, because %s", reason(err, Options.Profile))
+		}
+	}
+	if Options.CJK != "" {
+		rule, err := internal.NewCJKRule(Options.CJK)
+		if err != nil {
+			log.Fatalf("failed to apply --cjk, because %s", reason(err, Options.Profile))
+		}
+		// Romanize before Hangul syllables are decomposed, or first if the
+		// profile does not decompose names.
+		if err := config.Sanitizer.InsertBefore("decompose-nfd", rule); err != nil {
+			config.Sanitizer = internal.NewSanitizer(append([]internal.Rule{rule}, config.Sanitizer.Rules()...)...)
+		}
+	}
+	if Options.Emoji != "" {
+		rule, err := internal.NewEmojiRule(Options.Emoji)
+		if err != nil {
+			log.Fatalf("failed to apply --emoji, because %s", reason(err, Options.Profile))
+		}
+		// Replace emoji before other rules take their sequences apart, but
+		// after mojibake is repaired.
+		if err := config.Sanitizer.InsertBefore("replace-symbols", rule); err != nil {
+			config.Sanitizer = internal.NewSanitizer(append([]internal.Rule{rule}, config.Sanitizer.Rules()...)...)
+		}
+	}
+	if Options.Separators != "" {
+		policy, err := internal.ParseSeparatorPolicy(Options.Separators)
+		if err != nil {
+			log.Fatalf("failed to apply --separators, because %s", reason(err, Options.Profile))
+		}
+		// Normalize separators after other rules replaced characters with
+		// separators, but before names are trimmed.
+		rule := internal.NewSeparatorRule(policy)
+		if err := config.Sanitizer.InsertBefore("trim-dots-and-spaces", rule); err != nil {
+			config.Sanitizer = internal.NewSanitizer(append(config.Sanitizer.Rules(), rule)...)
+		}
+	}
+	if Options.Case != "" {
+		rule, err := internal.NewCaseRule(Options.Case)
+		if err != nil {
+			log.Fatalf("failed to apply --case, because %s", reason(err, Options.Profile))
+		}
+		// Change case before separators are normalized and names are
+		// trimmed.
+		if err := config.Sanitizer.InsertBefore("normalize-separators", rule); err != nil {
+			if err := config.Sanitizer.InsertBefore("trim-dots-and-spaces", rule); err != nil {
+				config.Sanitizer = internal.NewSanitizer(append(config.Sanitizer.Rules(), rule)...)
+			}
+		}
+		config.CaseInsensitive = true
+	}
+	if Options.RulesFile != "" {
+		rulesFile, err := internal.LoadRulesFile(Options.RulesFile)
+		if err != nil {
+			log.Fatalf("failed to load rules file, because %s", err.Error())
+		}
+		rules, err := rulesFile.Combine(config.Sanitizer.Rules())
+		if err != nil {
+			log.Fatalf("failed to load rules file, because %s: %s", Options.RulesFile, err.Error())
+		}
+		config.Sanitizer = internal.NewSanitizer(rules...)
+	}
+	for _, name := range Options.DisableRules {
+		if err := config.Sanitizer.Remove(name); err != nil {
+			log.Fatalf("failed to apply --disable-rule, because %s", reason(err, Options.Profile))
+		}
+	}
+	if Options.Reversible {
+		// The reversible encoding replaces all sanitize rules, so the
+		// options that change them would be ignored.
+		for _, option := range []struct {
+			name string
+			set  bool
+		}{
+			{"--trim-mode", Options.TrimMode != "trim"},
+			{"--locale", len(Options.Locale) > 0},
+			{"--mojibake-threshold", Options.MojibakeThreshold != internal.DefaultMojibakeThreshold},
+			{"--cyrillic", Options.Cyrillic != "bgn" || Options.CyrillicLanguage != "ru"},
+			{"--scripts", Options.Scripts != "all"},
+			{"--cjk", Options.CJK != ""},
+			{"--emoji", Options.Emoji != ""},
+			{"--separators", Options.Separators != ""},
+			{"--case", Options.Case != ""},
+			{"--rules", Options.RulesFile != ""},
+			{"--disable-rule", len(Options.DisableRules) > 0},
+			{"--normalize-only", Options.NormalizeOnly},
+		} {
+			if option.set {
+				log.Fatalf("failed to apply --reversible, because it replaces all sanitize rules and thus cannot be combined with %s", option.name)
+			}
+		}
+		if err := config.ApplyReversible(profile); err != nil {
+			log.Fatalf("failed to apply --reversible, because %s", reason(err, Options.Profile))
+		}
+	}
+	if Options.Normalization != "" || Options.NormalizeOnly {
+		form := Options.Normalization
+		if form == "" {
+			form = "nfc"
+		}
+		if err := config.ApplyNormalization(form, Options.NormalizeOnly); err != nil {
+			log.Fatalf("failed to apply --normalization, because %s", reason(err, Options.Profile))
+		}
+	}
+	if Options.StrictASCII != "" {
+		// Strict ASCII runs last, after all other rules had their chance
+		// to transliterate a name.
+		if err := config.ApplyStrictASCII(Options.StrictASCII, profile); err != nil {
+			log.Fatalf("failed to apply --strict-ascii, because %s", reason(err, Options.Profile))
+		}
+	}
+	if len(Options.Keep) > 0 || len(Options.KeepCategories) > 0 || len(Options.KeepScripts) > 0 {
+		allowlist, err := internal.NewAllowlist(strings.Join(Options.Keep, ""), Options.KeepCategories, Options.KeepScripts)
+		if err != nil {
+			log.Fatalf("failed to apply --keep, because %s", reason(err, Options.Profile))
+		}
+		config.Sanitizer.SetAllowlist(allowlist)
+	}
+	if Options.ListRules {
+		listRules(config.Sanitizer)
+		os.Exit(0)
+	}
+	if Options.Args.Folder == "" {
+		usage(parser)
 	}
 	if Options.MaxRenameAttempts < 1 {
 		log.Fatalf("max number of rename attempts must be >= 1, you provided %d", Options.MaxRenameAttempts)
@@ -69,15 +281,31 @@ Suggestions? Bugs? Questions? Go to https://github.com/miguno/sauber/`
 			Options.Truncate)
 	}
 
-	config := internal.Config{
-		SkipDirectories:          internal.DefaultSkipDirectories,
-		MaxRenameAttemptsPerPath: Options.MaxRenameAttempts,
-		MaxBasenameLength:        Options.Truncate,
-		SilentMode:               Options.Silent,
+	if Options.Explain {
+		os.Exit(explain(Options.Args.Folder, config))
 	}
-
 	if Options.Args.Folder != "" {
 		rootPath := Options.Args.Folder
+		for _, subtreeLocale := range subtreeLocales {
+			if Options.NormalizeOnly {
+				// Normalized names do not depend on the locale.
+				break
+			}
+			path, err := subtreePath(rootPath, subtreeLocale[0])
+			if err == nil {
+				sanitizer := internal.NewSanitizer(config.Sanitizer.Rules()...)
+				sanitizer.SetAllowlist(config.Sanitizer.Allowlist())
+				if err = applyLocale(sanitizer, subtreeLocale[1]); err == nil {
+					if config.Subtrees == nil {
+						config.Subtrees = map[string]*internal.Sanitizer{}
+					}
+					config.Subtrees[path] = sanitizer
+				}
+			}
+			if err != nil {
+				log.Fatalf("failed to apply --locale, because %s", reason(err, Options.Profile))
+			}
+		}
 		root, err := internal.Find(rootPath, config.SkipDirectories)
 		if err != nil {
 			log.Fatalf("failed to access or list contents of '%s', because %s",
@@ -88,9 +316,93 @@ Suggestions? Bugs? Questions? Go to https://github.com/miguno/sauber/`
 	}
 }
 
+// usage prints the help and examples, and exits.
+func usage(parser *flags.Parser) {
+	parser.WriteHelp(os.Stderr)
+	s := `
+sauber sanitizes the names of files and directories by replacing umlauts,
+accents, and similar diacritics.  By default, it performs a dry run to
+let you verify any changes it would make.
+
+Examples:
+  # Perform a dry run of sanitizing /volume1/music (including) and all
+  # its sub-directories and files.  This operation does not modify
+  # any data, it only reports what sauber *would* change.
+  $ sauber /volume1/music
+
+  # Sanitize /volume1/music (including) and all its sub-directories and files.
+  # *** WARNING: This command modifies your data! Always do a dry run first! ***
+  $ sauber --force /volume1/music
+
+  # Sanitize names so they also work on an exFAT-formatted USB drive.
+  $ sauber --profile smb+exfat /volume1/music
+
+  # Encode names reversibly, and print the original name of an encoded name.
+  $ sauber --reversible /volume1/music
+  $ sauber --decode 'M%C3%BCller%_'
+
+  # Show how each sanitize rule changes a name, character by character.
+  $ sauber --explain --profile windows 'Fö?.txt'
+
+Suggestions? Bugs? Questions? Go to https://github.com/miguno/sauber/`
+	_, _ = fmt.Fprintln(os.Stderr, s)
+	os.Exit(1)
+}
+
 func process(isActualRun bool, node *internal.FsNode, config internal.Config) {
 	err := internal.Rename(isActualRun, node, config)
 	if err != nil {
 		log.Fatal(err.Error())
 	}
 }
+
+// reason returns why an option could not be applied.  Options that change a
+// rule which the profile does without, e.g. --locale with the "windows"
+// profile, which does not expand umlauts, are rejected by naming the rule.
+func reason(err error, profile string) string {
+	var noRule *internal.NoRuleError
+	if profile != "" && errors.As(err, &noRule) {
+		return fmt.Sprintf("profile '%s' has no sanitize rule '%s' for the option to change", profile, noRule.Name)
+	}
+	return err.Error()
+}
+
+func applyLocale(sanitizer *internal.Sanitizer, locale string) error {
+	rule, err := internal.NewLocaleRule(locale)
+	if err != nil {
+		return err
+	}
+	if err := sanitizer.Replace("replace-umlauts", rule); err != nil {
+		return err
+	}
+	rule, err = internal.NewSymbolRule(locale)
+	if err != nil {
+		return err
+	}
+	return sanitizer.Replace("replace-symbols", rule)
+}
+
+// subtreePath returns the path of a folder as it appears below the root path,
+// e.g. "music/svenska" for the root "music", even if the folder is given as an
+// absolute path.
+func subtreePath(root, path string) (string, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(absRoot, absPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("'%s' is not inside '%s'", path, root)
+	}
+	return filepath.Join(root, rel), nil
+}
+
+func listRules(sanitizer *internal.Sanitizer) {
+	for i, rule := range sanitizer.Rules() {
+		fmt.Printf("%3d. %s\n", i+1, rule.Name())
+	}
+}
@@ -5,6 +5,7 @@ go 1.25.0
 require (
 	github.com/fatih/color v1.18.0
 	github.com/jessevdk/go-flags v1.6.1
+	github.com/pelletier/go-toml/v2 v2.3.1
 	github.com/stretchr/testify v1.11.1
 	golang.org/x/text v0.32.0
 )
@@ -0,0 +1,104 @@
+package internal
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Allowlist lists the characters that a sanitizer keeps, e.g. umlauts for
+// clients that handle them fine (see Sanitizer.SetAllowlist).
+type Allowlist struct {
+	runes  map[rune]bool
+	tables []*unicode.RangeTable
+}
+
+// NewAllowlist returns an allowlist of the given runes, of the runes in the
+// given Unicode categories, like "Lu" (uppercase letters) or "N" (numbers),
+// and of the runes of the given Unicode scripts, like "Han".  Script names are
+// matched regardless of case.
+func NewAllowlist(runes string, categories, scripts []string) (*Allowlist, error) {
+	a := &Allowlist{runes: map[rune]bool{}}
+	// Keep 'ä' even if it is passed as 'a' followed by U+0308 (see
+	// localeRule.lookup).
+	for _, c := range norm.NFC.String(runes) {
+		a.runes[c] = true
+	}
+	for _, category := range categories {
+		table, ok := unicode.Categories[category]
+		if !ok {
+			return nil, fmt.Errorf("unknown Unicode category '%s'", category)
+		}
+		a.tables = append(a.tables, table)
+	}
+	for _, script := range scripts {
+		table, ok := lookupUnicodeScript(script)
+		if !ok {
+			return nil, fmt.Errorf("unknown Unicode script '%s'", script)
+		}
+		a.tables = append(a.tables, table)
+	}
+	return a, nil
+}
+
+func lookupUnicodeScript(name string) (*unicode.RangeTable, bool) {
+	for script, table := range unicode.Scripts {
+		if strings.EqualFold(script, name) {
+			return table, true
+		}
+	}
+	return nil, false
+}
+
+// Allows reports whether the allowlist contains the rune.
+func (a *Allowlist) Allows(r rune) bool {
+	return a.runes[r] || unicode.IsOneOf(a.tables, r)
+}
+
+// kept returns the runes of name that the allowlist contains, in order.
+func (a *Allowlist) kept(name string) []rune {
+	var kept []rune
+	for _, c := range name {
+		if a.Allows(c) {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+// apply applies a rule to the name such that the rule does not change any
+// of the runes that the allowlist contains.  The rule is applied to the name
+// as a whole if it leaves those runes alone, so that rules like
+// "trim-dots-and-spaces" see the whole name.  Otherwise, the rule is applied
+// separately to each part of the name between the runes to keep.  apply also
+// returns the runes that the rule would have changed.
+func (a *Allowlist) apply(apply func(string) string, name string) (string, []rune) {
+	after := apply(name)
+	if slices.Equal(a.kept(name), a.kept(after)) {
+		return after, nil
+	}
+	var b, part strings.Builder
+	var changed []rune
+	flush := func() {
+		if part.Len() > 0 {
+			b.WriteString(apply(part.String()))
+			part.Reset()
+		}
+	}
+	for _, c := range name {
+		if !a.Allows(c) {
+			part.WriteRune(c)
+			continue
+		}
+		flush()
+		b.WriteRune(c)
+		if apply(string(c)) != string(c) && !slices.Contains(changed, c) {
+			changed = append(changed, c)
+		}
+	}
+	flush()
+	return b.String(), changed
+}
@@ -0,0 +1,58 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAllowlist(t *testing.T) {
+	a, err := NewAllowlist("äö", []string{"Nd"}, []string{"han"})
+	assert.NoError(t, err)
+	assert.True(t, a.Allows('ä'), "decomposed runes are composed")
+	assert.True(t, a.Allows('ö'))
+	assert.True(t, a.Allows('7'))
+	assert.True(t, a.Allows('中'))
+	assert.False(t, a.Allows('a'))
+	assert.False(t, a.Allows('ü'))
+
+	_, err = NewAllowlist("", []string{"Xx"}, nil)
+	assert.ErrorContains(t, err, "unknown Unicode category 'Xx'")
+	_, err = NewAllowlist("", nil, []string{"Klingon"})
+	assert.ErrorContains(t, err, "unknown Unicode script 'Klingon'")
+}
+
+func TestSanitizerKeepsAllowlist(t *testing.T) {
+	umlauts, _ := NewAllowlist("äöüÄÖÜß", nil, nil)
+	s := NewSanitizer(DefaultRules()...)
+	s.SetAllowlist(umlauts)
+	assert.Equal(t, "Lied über Größe.mp3", s.Sanitize("Lied über Größe.mp3"))
+	assert.Equal(t, "Ärger-Cafe_.txt", s.Sanitize("Ärger\x07Café?.txt"), "other characters are still replaced")
+	assert.Equal(t, "Lied über", s.Sanitize("Lied über. "), "rules like trimming see the whole name")
+	assert.Equal(t, "Größe", s.Sanitize("Größe"), "decomposed umlauts are kept once composed")
+
+	han, _ := NewAllowlist("", nil, []string{"Han"})
+	cjk, _ := NewCJKRule("ja")
+	s = NewSanitizer(append([]Rule{cjk}, DefaultRules()...)...)
+	s.SetAllowlist(han)
+	assert.Equal(t, "東京 Sushi", s.Sanitize("東京 すし"))
+
+	s.SetAllowlist(nil)
+	assert.Equal(t, "Tokyo Sushi", s.Sanitize("東京 すし"))
+}
+
+func TestSanitizerNotesKeptCharacters(t *testing.T) {
+	umlauts, _ := NewAllowlist("äöüß", nil, nil)
+	s := NewSanitizer(DefaultRules()...)
+	s.SetAllowlist(umlauts)
+	assert.Equal(t, []string{
+		`kept 'ü', which would have become "ue" without the allowlist`,
+		`kept 'ö', which would have become "oe" without the allowlist`,
+		`kept 'ß', which would have become "ss" without the allowlist`,
+	}, s.Notes("Lied über Größe.mp3"))
+
+	steps := s.Trace("über")
+	assert.Equal(t, "replace-umlauts", steps[4].Rule.Name())
+	assert.Equal(t, []rune{'ü'}, steps[4].Kept)
+	assert.Empty(t, steps[0].Kept)
+}
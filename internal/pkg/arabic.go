@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// arabicLetters maps the consonants of Arabic, and the additional letters of
+// Persian and Urdu, in the spirit of the UNGEGN romanization without
+// diacritics.  The letters 'و' and 'ي', which are consonants or long vowels,
+// are handled by transliterateArabic.
+var arabicLetters = map[rune]string{
+	'ء': "'", 'آ': "a", 'أ': "a", 'ؤ': "'", 'إ': "i", 'ئ': "'", 'ا': "a",
+	'ب': "b", 'ة': "a", 'ت': "t", 'ث': "th", 'ج': "j", 'ح': "h", 'خ': "kh",
+	'د': "d", 'ذ': "dh", 'ر': "r", 'ز': "z", 'س': "s", 'ش': "sh", 'ص': "s",
+	'ض': "d", 'ط': "t", 'ظ': "z", 'ع': "'", 'غ': "gh", 'ف': "f", 'ق': "q",
+	'ك': "k", 'ل': "l", 'م': "m", 'ن': "n", 'ه': "h", 'ى': "a", 'ٱ': "a",
+	// Persian and Urdu
+	'پ': "p", 'چ': "ch", 'ژ': "zh", 'ک': "k", 'گ': "g", 'ی': "y", 'ٹ': "t",
+	'ڈ': "d", 'ڑ': "r", 'ں': "n", 'ھ': "h", 'ہ': "h", 'ۀ': "h", 'ے': "e",
+	// vowel signs (harakat), which are usually omitted in writing
+	'َ': "a", 'ِ': "i", 'ُ': "u", 'ً': "an", 'ٍ': "in", 'ٌ': "un", 'ْ': "",
+	'ٰ': "a", 'ٓ': "", 'ٔ': "", 'ٕ': "",
+	'ـ': "", // tatweel, which only stretches the text
+}
+
+const arabicShadda = 'ّ'
+
+// transliterateArabic romanizes a run of Arabic script.  Short vowels are
+// only transliterated if they are written, which they rarely are, so that
+// "كتاب" becomes "ktab".  The article "ال" becomes "al-", as in "al-qahra".
+func transliterateArabic(run []rune) string {
+	runes := []rune(norm.NFC.String(string(run)))
+	var b strings.Builder
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		wordStart := i == 0 || !isArabicLetter(runes[i-1])
+		var latin string
+		switch {
+		case wordStart && c == 'ا' && i+2 < len(runes) && runes[i+1] == 'ل' && isArabicLetter(runes[i+2]):
+			b.WriteString("al-")
+			i++
+			continue
+		case c == 'ع' && wordStart:
+			// The initial 'ayn is usually dropped, as in "Umar".
+		case c == arabicShadda:
+			// The shadda doubles the preceding consonant, see below.
+		case c == 'و' || c == 'ي' || c == 'ی':
+			latin = arabicSemivowel(runes, i, wordStart)
+		default:
+			var ok bool
+			if latin, ok = arabicLetters[c]; !ok {
+				if latin, ok = transliterateDigit(c); !ok {
+					latin = string(c)
+				}
+			}
+		}
+		if hasArabicShadda(runes, i) {
+			latin += latin
+		}
+		b.WriteString(latin)
+	}
+	return b.String()
+}
+
+// hasArabicShadda reports whether the letter at runes[i] carries a shadda,
+// possibly next to a vowel sign.
+func hasArabicShadda(runes []rune, i int) bool {
+	for j := i + 1; j < len(runes) && unicode.Is(unicode.Mn, runes[j]); j++ {
+		if runes[j] == arabicShadda {
+			return !unicode.Is(unicode.Mn, runes[i])
+		}
+	}
+	return false
+}
+
+// arabicSemivowel transliterates the letter at runes[i].  The letters 'و' and
+// 'ي' are consonants ("w", "y") at the beginning of a word and before vowels,
+// and long vowels ("u", "i") otherwise.
+func arabicSemivowel(runes []rune, i int, wordStart bool) string {
+	c := runes[i]
+	if c != 'و' && c != 'ي' && c != 'ی' {
+		return arabicLetters[c]
+	}
+	consonant := wordStart
+	if i+1 < len(runes) && strings.ContainsRune("اوييىةَُِ", runes[i+1]) {
+		consonant = true
+	}
+	switch {
+	case c == 'و' && consonant:
+		return "w"
+	case c == 'و':
+		return "u"
+	case consonant:
+		return "y"
+	}
+	return "i"
+}
+
+func isArabicLetter(c rune) bool {
+	_, ok := arabicLetters[c]
+	return ok || c == 'و' || c == 'ي' || c == 'ی'
+}
@@ -0,0 +1,25 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransliterateArabic(t *testing.T) {
+	examples := map[string]string{
+		"كتاب":     "ktab",
+		"القاهرة":  "al-qahra",
+		"بيروت":    "birut",
+		"سوريا":    "surya",
+		"دمشق":     "dmshq",
+		"عمر":      "mr",
+		"مُحَمَّد": "muhammad",
+		"پاکستان":  "pakstan",
+		"٢٠٢٤":     "2024",
+		"۱۴۰۳":     "1403",
+	}
+	for arabic, latin := range examples {
+		assert.Equal(t, latin, transliterateArabic([]rune(arabic)), arabic)
+	}
+}
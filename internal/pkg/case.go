@@ -0,0 +1,131 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// CaseStyles lists the styles that can be passed to NewCaseRule.
+var CaseStyles = []string{"lower", "upper", "title", "snake", "kebab"}
+
+// NewCaseRule returns the rule "change-case", which writes names in the given
+// style: "lower" ("my song.mp3"), "upper" ("MY SONG.mp3"), "title" ("My
+// Song.mp3"), "snake" ("my_song.mp3"), or "kebab" ("my-song.mp3").
+//
+// Words are separated by spaces, underscores, hyphens, and other punctuation,
+// and by changes in case, so that "myFavoriteSong" and "HTMLParser" have
+// three and two words.  Apostrophes do not separate words.  The extensions
+// of files are kept intact, as is every part of a name between dots, so that
+// "My Song.tar.gz" becomes "my-song.tar.gz".  The styles "lower", "snake",
+// and "kebab" lowercase extensions, too.
+func NewCaseRule(style string) (Rule, error) {
+	for _, s := range CaseStyles {
+		if s == style {
+			return caseRule{style: style}, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown case style '%s' (must be one of: %s)", style, strings.Join(CaseStyles, ", "))
+}
+
+type caseRule struct {
+	style string
+}
+
+func (r caseRule) Name() string { return "change-case" }
+
+func (r caseRule) Apply(name string) string {
+	return r.ApplyEntry(name, false)
+}
+
+func (r caseRule) ApplyEntry(name string, isDir bool) string {
+	stem, extension := splitExtension(name, isDir)
+	if stem == "" {
+		// hidden files like ".bashrc"
+		stem, extension = name, ""
+	}
+	parts := strings.Split(stem, ".")
+	for i, part := range parts {
+		parts[i] = r.style1(part)
+	}
+	if r.style != "upper" && r.style != "title" {
+		extension = strings.ToLower(extension)
+	}
+	return strings.Join(parts, ".") + extension
+}
+
+// style1 writes a part of a name without dots in the style of the rule.
+func (r caseRule) style1(part string) string {
+	switch r.style {
+	case "lower":
+		return strings.ToLower(part)
+	case "upper":
+		return strings.ToUpper(part)
+	case "title":
+		var b strings.Builder
+		inWord := false
+		for _, c := range part {
+			switch {
+			case isLetterOrDigit(c) && !inWord:
+				b.WriteRune(unicode.ToUpper(c))
+			case isLetterOrDigit(c):
+				b.WriteRune(unicode.ToLower(c))
+			default:
+				b.WriteRune(c)
+			}
+			inWord = isLetterOrDigit(c) || isApostrophe(c) && inWord
+		}
+		return b.String()
+	}
+	words := splitWords(part)
+	if len(words) == 0 {
+		return part
+	}
+	separator := "_"
+	if r.style == "kebab" {
+		separator = "-"
+	}
+	return strings.ToLower(strings.Join(words, separator))
+}
+
+// isLetterOrDigit reports whether the rune is a letter, a digit, or a mark
+// that belongs to a letter.
+func isLetterOrDigit(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c) || unicode.Is(unicode.M, c)
+}
+
+func isApostrophe(c rune) bool {
+	return c == '\'' || c == '’' || c == 'ʼ'
+}
+
+// splitWords splits a part of a name into words (see NewCaseRule).
+func splitWords(part string) []string {
+	var words []string
+	var word []rune
+	runes := []rune(part)
+	for i, c := range runes {
+		if isApostrophe(c) && len(word) > 0 {
+			continue
+		}
+		if !isLetterOrDigit(c) {
+			if len(word) > 0 {
+				words = append(words, string(word))
+				word = nil
+			}
+			continue
+		}
+		if len(word) > 0 && unicode.IsUpper(c) {
+			prev := word[len(word)-1]
+			// "myFavorite" and "HTMLParser"
+			if unicode.IsLower(prev) || unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+				words = append(words, string(word))
+				word = nil
+			}
+		}
+		word = append(word, c)
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return words
+}
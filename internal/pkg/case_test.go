@@ -0,0 +1,68 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCaseRule(t *testing.T) {
+	apply := func(style, name string, isDir bool) string {
+		rule, err := NewCaseRule(style)
+		assert.NoError(t, err)
+		return rule.(EntryRule).ApplyEntry(name, isDir)
+	}
+	assert.Equal(t, "my favorite song (live).mp3", apply("lower", "My Favorite Song (LIVE).MP3", false))
+	assert.Equal(t, "MY FAVORITE SONG (LIVE).MP3", apply("upper", "My Favorite Song (live).MP3", false))
+	assert.Equal(t, "My Favorite Song (Live).MP3", apply("title", "my FAVORITE song (live).MP3", false))
+	assert.Equal(t, "Don't Stop", apply("title", "don't stop", true))
+	assert.Equal(t, "my_favorite_song_live.mp3", apply("snake", "My Favorite Song (LIVE).MP3", false))
+	assert.Equal(t, "my-favorite-song-live.mp3", apply("kebab", "My Favorite Song (LIVE).MP3", false))
+	assert.Equal(t, "my-favorite-song", apply("kebab", "myFavoriteSong", true))
+	assert.Equal(t, "html-parser-2.go", apply("kebab", "HTMLParser 2.go", false))
+	assert.Equal(t, "dont-stop", apply("kebab", "Don't Stop", true))
+
+	assert.Equal(t, "my-song.tar.gz", apply("kebab", "My Song.tar.GZ", false), "keep the parts between dots")
+	assert.Equal(t, "vol-1.best-of", apply("kebab", "Vol 1.Best Of", true), "directories have no extension")
+	assert.Equal(t, ".bash-profile", apply("kebab", ".bash_profile", false), "keep hidden files hidden")
+	assert.Equal(t, "---", apply("kebab", "---", false), "keep names without words")
+
+	_, err := NewCaseRule("camel")
+	assert.ErrorContains(t, err, "unknown case style 'camel' (must be one of: lower, upper, title, snake, kebab)")
+}
+
+func TestSanitizeEntryWithCaseRule(t *testing.T) {
+	rule, _ := NewCaseRule("kebab")
+	s := NewSanitizer(DefaultRules()...)
+	assert.NoError(t, s.InsertBefore("trim-dots-and-spaces", rule))
+	assert.Equal(t, "uebermut-im-maerz.mp3", s.SanitizeEntry("Übermut im März.MP3", false))
+	assert.Equal(t, "con_", s.SanitizeEntry("CON", true), "rewrite reserved device names")
+}
+
+func TestRenameChangesCaseOnCaseInsensitiveFileSystems(t *testing.T) {
+	rule, _ := NewCaseRule("lower")
+	config := Config{
+		MaxRenameAttemptsPerPath: 10,
+		MaxBasenameLength:        255,
+		SilentMode:               true,
+		Sanitizer:                NewSanitizer(rule),
+		CaseInsensitive:          true,
+	}
+	root := FsNode{name: "share", originalPath: "share", isDir: true}
+	root.AddNestedChild("share/README", false)
+	root.AddNestedChild("share/ReadMe", false)
+	root.AddNestedChild("share/Notes.txt", false)
+	assert.NoError(t, Rename(false, &root, config))
+	// "README" cannot become "readme" while "ReadMe" exists
+	assert.Equal(t, []string{"share", "share/readme_1", "share/readme", "share/notes.txt"}, root.Paths())
+
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "Notes.TXT"), nil, 0o644))
+	assert.NoError(t, renamePath(filepath.Join(dir, "Notes.TXT"), filepath.Join(dir, "notes.txt")))
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "notes.txt", entries[0].Name())
+}
@@ -0,0 +1,174 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+)
+
+// Charset is a legacy byte encoding of names, which old systems wrote before
+// UTF-8 became common, e.g. CP437 for the file names of MS-DOS.
+type Charset struct {
+	Name        string
+	Description string
+	encoding    encoding.Encoding
+}
+
+// Charsets lists the supported legacy encodings, in the order in which
+// NewNameDecoder prefers them when it detects the encoding of a name.
+var Charsets = []Charset{
+	{Name: "cp1252", Description: "Windows-1252, Western European Windows", encoding: charmap.Windows1252},
+	{Name: "cp850", Description: "Western European MS-DOS", encoding: charmap.CodePage850},
+	{Name: "cp437", Description: "US MS-DOS", encoding: charmap.CodePage437},
+	{Name: "shift-jis", Description: "Japanese Windows and MS-DOS", encoding: japanese.ShiftJIS},
+	{Name: "latin1", Description: "ISO 8859-1", encoding: charmap.ISO8859_1},
+}
+
+// CharsetNames returns the names of the supported legacy encodings.
+func CharsetNames() []string {
+	var names []string
+	for _, c := range Charsets {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+// minCharsetPlausibility is the plausibility (see charsetPlausibility) that
+// a name must reach when its encoding is detected.
+const minCharsetPlausibility = 0.6
+
+// NameDecoder decodes names that are not valid UTF-8, like `convmv` does.
+type NameDecoder struct {
+	charsets []Charset
+	// detect is true if the decoder detects the encoding of names.
+	detect bool
+}
+
+// NewNameDecoder returns a decoder for names in the given legacy encoding
+// (see Charsets), or, for "auto", for names in any of them.  In that case,
+// the decoder picks the encoding whose result is the most plausible, and
+// refuses to decode names whose result is not plausible enough.
+func NewNameDecoder(charset string) (*NameDecoder, error) {
+	if charset == "auto" {
+		return &NameDecoder{charsets: Charsets, detect: true}, nil
+	}
+	for _, c := range Charsets {
+		if c.Name == charset {
+			return &NameDecoder{charsets: []Charset{c}}, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown charset '%s' (must be one of: auto, %s)", charset, strings.Join(CharsetNames(), ", "))
+}
+
+// Decode decodes the name from the legacy encoding and returns the name in
+// UTF-8 and the name of the encoding.  Names that are valid UTF-8 already
+// are returned unchanged with an empty encoding.  Decode returns an error if
+// the name decodes to control characters, or to the replacement character
+// U+FFFD for bytes that the encoding does not define, or, when detecting
+// the encoding, if the name does not decode to plausible characters.
+func (d *NameDecoder) Decode(name string) (string, string, error) {
+	if utf8.ValidString(name) {
+		return name, "", nil
+	}
+	best, bestCharset, bestPlausibility := "", "", -1.0
+	for _, c := range d.charsets {
+		decoded, err := c.encoding.NewDecoder().String(name)
+		if err != nil {
+			continue
+		}
+		// Of equally plausible encodings, prefer multi-byte encodings
+		// like Shift-JIS, which decode to fewer characters, then the
+		// first.
+		p := charsetPlausibility(decoded)
+		if p > bestPlausibility || p == bestPlausibility && utf8.RuneCountInString(decoded) < utf8.RuneCountInString(best) {
+			best, bestCharset, bestPlausibility = decoded, c.Name, p
+		}
+	}
+	if !validDecoding(best) || d.detect && bestPlausibility < minCharsetPlausibility {
+		var tried []string
+		for _, c := range d.charsets {
+			tried = append(tried, c.Name)
+		}
+		return "", "", fmt.Errorf("name '%s' is not valid UTF-8, and could not be decoded from %s", printableName(name), strings.Join(tried, ", "))
+	}
+	return best, bestCharset, nil
+}
+
+// charsetPlausibility rates how likely a decoded name is what the name was
+// meant to be, from 0 to 1, by its non-ASCII and control characters: letters, marks, and
+// digits are likely if they belong to the same script as the letters next to
+// them, so that "Rätsel" is more likely than "R辰sel" with a Shift-JIS
+// character.  Punctuation and symbols, like the box drawing characters of
+// CP437, are less likely, and control characters, and U+FFFD for bytes that
+// the encoding does not define, are implausible.
+func charsetPlausibility(decoded string) float64 {
+	runes := []rune(decoded)
+	var sum float64
+	n := 0
+	for i, c := range runes {
+		if c >= ' ' && c < utf8.RuneSelf {
+			continue
+		}
+		n++
+		switch {
+		case c == utf8.RuneError:
+		case unicode.In(c, unicode.L, unicode.M, unicode.N):
+			sum += 1
+			for _, j := range []int{i - 1, i + 1} {
+				if j >= 0 && j < len(runes) && unicode.IsLetter(runes[j]) && scriptGroup(runes[j]) != scriptGroup(c) {
+					sum -= 0.35
+				}
+			}
+		case unicode.In(c, unicode.P, unicode.S, unicode.Z):
+			sum += 0.5
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return sum / float64(n)
+}
+
+// validDecoding reports whether a decoded name is free of control characters
+// and of the replacement character U+FFFD.
+func validDecoding(decoded string) bool {
+	return decoded != "" && !strings.ContainsFunc(decoded, func(c rune) bool {
+		return c == utf8.RuneError || unicode.IsControl(c)
+	})
+}
+
+// scriptGroup returns the Unicode script of the rune, where Han, Hiragana,
+// and Katakana, which Japanese mixes, count as one.
+func scriptGroup(r rune) string {
+	for name, table := range unicode.Scripts {
+		if unicode.Is(table, r) {
+			switch name {
+			case "Hiragana", "Katakana":
+				return "Han"
+			}
+			return name
+		}
+	}
+	return ""
+}
+
+// printableName writes the bytes of a name that are not valid UTF-8, and
+// control characters, as `\xff`, so that the name can be printed.
+func printableName(name string) string {
+	var b strings.Builder
+	for i := 0; i < len(name); {
+		c, size := utf8.DecodeRuneInString(name[i:])
+		if c == utf8.RuneError && size == 1 || c < ' ' || c == 0x7F {
+			_, _ = fmt.Fprintf(&b, `\x%02x`, name[i])
+		} else {
+			b.WriteString(name[i : i+size])
+		}
+		i += size
+	}
+	return b.String()
+}
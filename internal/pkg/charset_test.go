@@ -0,0 +1,73 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNameDecoder(t *testing.T) {
+	auto, err := NewNameDecoder("auto")
+	assert.NoError(t, err)
+	decode := func(d *NameDecoder, name string) (string, string) {
+		decoded, charset, err := d.Decode(name)
+		assert.NoError(t, err, printableName(name))
+		return decoded, charset
+	}
+
+	decoded, charset := decode(auto, "R\xe4tsel.mp3")
+	assert.Equal(t, "Rätsel.mp3", decoded)
+	assert.Equal(t, "cp1252", charset)
+	decoded, charset = decode(auto, "R\x84tsel.mp3")
+	assert.Equal(t, "Rätsel.mp3", decoded)
+	assert.Equal(t, "cp850", charset, "CP1252 decodes 0x84 as '„'")
+	decoded, charset = decode(auto, "\x93\x8c\x8b\x9e.txt")
+	assert.Equal(t, "東京.txt", decoded)
+	assert.Equal(t, "shift-jis", charset)
+	decoded, charset = decode(auto, "\x83J\x83^\x83J\x83i.txt")
+	assert.Equal(t, "カタカナ.txt", decoded)
+	assert.Equal(t, "shift-jis", charset)
+	decoded, charset = decode(auto, "Rätsel.mp3")
+	assert.Equal(t, "Rätsel.mp3", decoded, "valid UTF-8 is kept")
+	assert.Equal(t, "", charset)
+
+	cp437, err := NewNameDecoder("cp437")
+	assert.NoError(t, err)
+	decoded, _ = decode(cp437, "Men\xa3.txt")
+	assert.Equal(t, "Menú.txt", decoded)
+	decoded, _ = decode(cp437, "R\xe4tsel.mp3")
+	assert.Equal(t, "RΣtsel.mp3", decoded, "a given encoding need not be plausible")
+	_, _, err = cp437.Decode("a\x01\xdb\xdb")
+	assert.ErrorContains(t, err, `name 'a\x01\xdb\xdb' is not valid UTF-8, and could not be decoded from cp437`)
+	_, _, err = auto.Decode("x\xff\x01")
+	assert.ErrorContains(t, err, `name 'x\xff\x01' is not valid UTF-8, and could not be decoded from cp1252, cp850, cp437, shift-jis, latin1`)
+
+	_, err = NewNameDecoder("ebcdic")
+	assert.ErrorContains(t, err, "unknown charset 'ebcdic' (must be one of: auto, cp1252, cp850, cp437, shift-jis, latin1)")
+}
+
+func TestRenameDecodesNames(t *testing.T) {
+	root := FsNode{name: "backup", originalPath: "backup", isDir: true}
+	root.AddNestedChild("backup/R\xe4tsel", true)
+	root.AddNestedChild("backup/R\xe4tsel/\x01\x02\xff", false)
+	root.AddNestedChild("backup/R\xe4tsel/Men\xfc.txt", false)
+	decoder, _ := NewNameDecoder("auto")
+	config := Config{
+		MaxRenameAttemptsPerPath: 10,
+		MaxBasenameLength:        255,
+		SilentMode:               true,
+		Decoder:                  decoder,
+	}
+	assert.NoError(t, Rename(false, &root, config))
+	expected := []string{
+		"backup",
+		"backup/Raetsel",
+		"backup/Raetsel/\x01\x02\xff",
+		"backup/Raetsel/Menue.txt",
+	}
+	assert.Equal(t, expected, root.Paths(), "undecodable names are kept")
+}
+
+func TestPrintableName(t *testing.T) {
+	assert.Equal(t, `R\xe4tsel/Rätsel\x07`, printableName("R\xe4tsel/Rätsel\a"))
+}
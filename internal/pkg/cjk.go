@@ -0,0 +1,187 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"embed"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// CJKLanguages lists the languages that can be passed to NewCJKRule.  The
+// language determines how Han characters are read: "zh" reads them in Mandarin
+// (Pinyin), "ja" in Japanese (Hepburn), and "ko" leaves them alone, because
+// Korean names are rarely written in Han characters.
+var CJKLanguages = []string{"zh", "ja", "ko"}
+
+//go:embed dict/zh.txt dict/ja.txt
+var cjkDictionaries embed.FS
+
+// NewCJKRule returns a rule that romanizes Chinese, Japanese, and Korean:
+// Hangul according to the Revised Romanization of Korean, kana according to
+// the modified Hepburn system, and Han characters according to an embedded
+// dictionary for the given language (see CJKLanguages): the Mandarin readings
+// of Unihan for "zh", and the readings of the words of IPADIC for "ja", which
+// are matched longest first.  Han characters that are not in the dictionary
+// are kept, so names may be romanized partially.
+//
+// Romanized words are capitalized and separated by spaces, e.g.
+// "千と千尋の神隠し" becomes "Sen to Chihiro no Kamigakushi".
+//
+// The rule must run before "decompose-nfd", which would otherwise decompose
+// Hangul syllables into their letters (jamo).
+func NewCJKRule(language string) (Rule, error) {
+	r := cjkRule{japanese: language == "ja"}
+	switch language {
+	case "zh", "ja":
+		var err error
+		if r.dictionary, err = loadCJKDictionary("dict/" + language + ".txt"); err != nil {
+			return nil, err
+		}
+	case "ko":
+	default:
+		return nil, fmt.Errorf("unknown CJK language '%s' (must be one of: %s)", language, strings.Join(CJKLanguages, ", "))
+	}
+	return r, nil
+}
+
+// cjkDictionary maps words (one or more runes) to their readings.
+type cjkDictionary struct {
+	readings map[string]string
+	// maxRunes is the length of the longest word.
+	maxRunes int
+}
+
+func loadCJKDictionary(path string) (cjkDictionary, error) {
+	d := cjkDictionary{readings: map[string]string{}}
+	data, err := cjkDictionaries.ReadFile(path)
+	if err != nil {
+		return d, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		word, reading, ok := strings.Cut(text, " ")
+		if !ok || word == "" || reading == "" {
+			return d, fmt.Errorf("%s: line %d: expected a word and its reading", path, line)
+		}
+		d.readings[word] = reading
+		d.maxRunes = max(d.maxRunes, utf8.RuneCountInString(word))
+	}
+	return d, scanner.Err()
+}
+
+// lookup returns the reading of the longest word at the start of runes, and
+// the number of runes of the word.
+func (d cjkDictionary) lookup(runes []rune) (string, int) {
+	for n := min(d.maxRunes, len(runes)); n > 0; n-- {
+		if reading, ok := d.readings[string(runes[:n])]; ok {
+			return reading, n
+		}
+	}
+	return "", 0
+}
+
+type cjkRule struct {
+	dictionary cjkDictionary
+	japanese   bool
+}
+
+func (r cjkRule) Name() string { return "romanize-cjk" }
+
+func (r cjkRule) Apply(name string) string {
+	runes := []rune(name)
+	w := &wordWriter{}
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		if isHangulSyllable(c) {
+			n := 1
+			for i+n < len(runes) && isHangulSyllable(runes[i+n]) {
+				n++
+			}
+			w.word(romanizeHangul(runes[i:i+n]), false)
+			i += n
+			continue
+		}
+		if unicode.Is(unicode.Han, c) || isKana(c) {
+			if reading, n := r.dictionary.lookup(runes[i:]); n > 0 {
+				w.word(reading, false)
+				i += n
+				continue
+			}
+		}
+		if isKana(c) {
+			// A run of kana ends where its script changes, or where a
+			// word of the dictionary begins.
+			n := 1
+			for i+n < len(runes) && isKana(runes[i+n]) &&
+				(unicode.Is(unicode.Hiragana, c) == unicode.Is(unicode.Hiragana, runes[i+n]) || runes[i+n] == 'ー') {
+				if _, m := r.dictionary.lookup(runes[i+n:]); m > 0 {
+					break
+				}
+				n++
+			}
+			kana := runes[i : i+n]
+			_, particle := japaneseParticles[string(kana)]
+			w.word(romanizeKana(kana), particle && r.japanese && w.words > 0)
+			i += n
+			continue
+		}
+		if c == '・' {
+			// The katakana middle dot separates words, as in "ハリー・ポッター".
+			c = ' '
+		}
+		w.rune(c)
+		i++
+	}
+	return w.b.String()
+}
+
+// japaneseParticles lists the particles that are written in lowercase, as in
+// "Sen to Chihiro no Kamigakushi".
+var japaneseParticles = map[string]struct{}{
+	"と": {}, "の": {}, "は": {}, "が": {}, "を": {}, "に": {}, "で": {}, "へ": {},
+	"も": {}, "や": {}, "か": {}, "から": {}, "まで": {}, "より": {},
+}
+
+// wordWriter joins romanized words with spaces, and separates them from
+// adjacent letters and digits.
+type wordWriter struct {
+	b strings.Builder
+	// words is the number of romanized words written so far.
+	words int
+	// afterWord is true if the last thing written was a romanized word.
+	afterWord bool
+}
+
+func (w *wordWriter) word(latin string, lowercase bool) {
+	last, _ := utf8.DecodeLastRuneInString(w.b.String())
+	if unicode.IsLetter(last) || unicode.IsDigit(last) {
+		w.b.WriteByte(' ')
+	}
+	for i, part := range strings.Split(latin, " ") {
+		if i > 0 {
+			w.b.WriteByte(' ')
+		}
+		if !lowercase && part != "" {
+			first, size := utf8.DecodeRuneInString(part)
+			part = string(unicode.ToUpper(first)) + part[size:]
+		}
+		w.b.WriteString(part)
+	}
+	w.words++
+	w.afterWord = true
+}
+
+func (w *wordWriter) rune(c rune) {
+	if w.afterWord && (unicode.IsLetter(c) || unicode.IsDigit(c)) {
+		w.b.WriteByte(' ')
+	}
+	w.b.WriteRune(c)
+	w.afterWord = false
+}
@@ -0,0 +1,111 @@
+package internal
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/unicode/norm"
+)
+
+func TestCJKRuleSeparatesWords(t *testing.T) {
+	japanese, err := NewCJKRule("ja")
+	require.NoError(t, err)
+	assert.Equal(t, "Sen to Chihiro no Kamigakushi", japanese.Apply("千と千尋の神隠し"))
+	assert.Equal(t, "Kimi no Na wa", japanese.Apply("君の名は"))
+	assert.Equal(t, "Harī Pottā", norm.NFC.String(japanese.Apply("ハリー・ポッター")))
+	// The dictionary lists no words of kana only, so a run of hiragana is
+	// one word, particles and all.
+	assert.Equal(t, "Tonarino Totoro.mkv", japanese.Apply("となりのトトロ.mkv"))
+
+	chinese, err := NewCJKRule("zh")
+	require.NoError(t, err)
+	assert.Equal(t, "Ni Hao Shi Jie", chinese.Apply("你好世界"))
+	assert.Equal(t, "Bei Jing 2008.jpg", chinese.Apply("北京2008.jpg"))
+	assert.Equal(t, "Zhou Jie Lun - Qing Hua Ci.mp3", chinese.Apply("周杰伦 - 青花瓷.mp3"))
+
+	korean, err := NewCJKRule("ko")
+	require.NoError(t, err)
+	assert.Equal(t, "Bangtansonyeondan - Bomnal.mp3", korean.Apply("방탄소년단 - 봄날.mp3"))
+	assert.Equal(t, "Seoul Yeohaeng", korean.Apply("서울 여행"))
+	assert.Equal(t, "BTS Ui Norae", korean.Apply("BTS의 노래"))
+}
+
+func TestCJKRuleRomanizesEverydayNames(t *testing.T) {
+	japanese, err := NewCJKRule("ja")
+	require.NoError(t, err)
+	assert.Equal(t, "Kaze no Tani no Naushika.mkv", japanese.Apply("風の谷のナウシカ.mkv"))
+	assert.Equal(t, "Natsuyasumi no Shashin.jpg", japanese.Apply("夏休みの写真.jpg"))
+	assert.Equal(t, "Kazoku Ryokō Kyōto 2019", japanese.Apply("家族旅行 京都 2019"))
+	assert.Equal(t, "Kaigi no Giji Roku.docx", japanese.Apply("会議の議事録.docx"))
+	assert.Equal(t, "Bara no Hanataba.jpg", japanese.Apply("薔薇の花束.jpg"))
+
+	chinese, err := NewCJKRule("zh")
+	require.NoError(t, err)
+	assert.Equal(t, "Wo Hu Cang Long.mkv", chinese.Apply("卧虎藏龙.mkv"))
+	assert.Equal(t, "Chun Jie Kuai Le.jpg", chinese.Apply("春节快乐.jpg"))
+	assert.Equal(t, "Fa Piao 2024 Nian 3 Yue.pdf", chinese.Apply("发票 2024年3月.pdf"))
+	assert.Equal(t, "Tai Wan Lü You", chinese.Apply("臺灣旅遊"))
+}
+
+// The 3,755 characters of level 1 of GB 2312, the most frequent characters
+// of simplified Chinese, all have a reading.
+func TestCJKRuleReadsFrequentChineseCharacters(t *testing.T) {
+	chinese, err := NewCJKRule("zh")
+	require.NoError(t, err)
+	decoder := simplifiedchinese.GBK.NewDecoder()
+	for hi := byte(0xB0); hi <= 0xD7; hi++ {
+		for lo := byte(0xA1); lo <= 0xFE && !(hi == 0xD7 && lo > 0xF9); lo++ {
+			c, err := decoder.String(string([]byte{hi, lo}))
+			require.NoError(t, err)
+			assert.NotEqual(t, c, chinese.Apply(c), "%s has no reading", c)
+		}
+	}
+}
+
+func TestCJKRuleKeepsUnknownHan(t *testing.T) {
+	chinese, err := NewCJKRule("zh")
+	require.NoError(t, err)
+	japanese, err := NewCJKRule("ja")
+	require.NoError(t, err)
+	korean, err := NewCJKRule("ko")
+	require.NoError(t, err)
+	// Words and characters that the dictionaries do not cover are kept,
+	// while the kana and the known characters around them are romanized.
+	assert.Equal(t, "𠮷 Noya", japanese.Apply("𠮷野家"))
+	assert.Equal(t, "Ki no 鬱", japanese.Apply("気の鬱"))
+	assert.Equal(t, "𠮷 Ye Jia", chinese.Apply("𠮷野家"))
+	assert.Equal(t, "Sushizanmai", japanese.Apply("すしざんまい"), "kana are romanized without the dictionary")
+	assert.Equal(t, "漢字", korean.Apply("漢字"))
+	assert.Equal(t, "Café.txt", japanese.Apply("Café.txt"))
+}
+
+func TestNewCJKRuleErrors(t *testing.T) {
+	_, err := NewCJKRule("vi")
+	assert.EqualError(t, err, "unknown CJK language 'vi' (must be one of: zh, ja, ko)")
+}
+
+func TestCJKDictionariesAreValid(t *testing.T) {
+	for _, language := range []string{"zh", "ja"} {
+		d, err := loadCJKDictionary("dict/" + language + ".txt")
+		assert.NoError(t, err)
+		assert.NotEmpty(t, d.readings)
+		valid := regexp.MustCompile(`^[a-zāīūēōü' ]+$`)
+		for word, reading := range d.readings {
+			if !valid.MatchString(reading) {
+				assert.Fail(t, "invalid reading", "%s: %q", word, reading)
+			}
+		}
+	}
+}
+
+func TestSanitizeWithCJKRule(t *testing.T) {
+	rule, err := NewCJKRule("ja")
+	require.NoError(t, err)
+	s := NewSanitizer(DefaultRules()...)
+	assert.NoError(t, s.InsertBefore("decompose-nfd", rule))
+	assert.Equal(t, "Tokyo Monogatari.mkv", s.Sanitize("東京物語.mkv"))
+	assert.Equal(t, "Kohi", s.Sanitize("コーヒー"))
+}
@@ -1,10 +1,146 @@
 package internal
 
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
 type Config struct {
 	SkipDirectories          map[string]bool
 	MaxRenameAttemptsPerPath int
 	MaxBasenameLength        int
-	SilentMode               bool
+	// MaxBasenameChars limits the length of a basename in UTF-16 code units
+	// rather than in bytes (cf. MaxBasenameLength).  0 means no limit.
+	MaxBasenameChars int
+	SilentMode       bool
+	// Sanitizer to apply to the names of files and directories.  If nil,
+	// the default rules are used (see DefaultRules).
+	Sanitizer *Sanitizer
+	// Subtrees maps the original paths of directories (see
+	// FsNode.OriginalPath) to the sanitizers that apply to them and everything
+	// below them, e.g. to sanitize a folder of Swedish music with the Swedish
+	// locale.  Paths are compared after filepath.Clean.
+	Subtrees map[string]*Sanitizer
+	// Charset reports whether sanitized names may contain the rune, or is nil
+	// if they may contain any rune.  Rename fails for names that contain
+	// other runes, unless the sanitizer's allowlist contains them (see
+	// ApplyStrictASCII).
+	Charset func(rune) bool
+	// Decoder decodes names that are not valid UTF-8 before they are
+	// sanitized.  If nil, such names are sanitized as they are.
+	Decoder *NameDecoder
+	// CaseInsensitive makes Rename treat names that differ in case only,
+	// like "Foo" and "foo", as colliding, as case-insensitive file systems
+	// do.  This matters when names are changed in case (see NewCaseRule).
+	CaseInsensitive bool
+	// Reversible makes Rename fail rather than truncate names or append
+	// suffixes, so that the original names can be decoded (see
+	// ApplyReversible).
+	Reversible bool
+}
+
+// ApplyProfile configures sanitizing for the given target system profile.
+// The profile's length limits only take effect if they are stricter than the
+// limits already configured.
+func (config *Config) ApplyProfile(profile Profile) {
+	config.Sanitizer = NewSanitizer(profile.Rules...)
+	config.MaxBasenameLength = minLimit(config.MaxBasenameLength, profile.MaxBasenameLength)
+	config.MaxBasenameChars = minLimit(config.MaxBasenameChars, profile.MaxBasenameChars)
+}
+
+// ApplyStrictASCII guarantees that sanitized names consist of the printable
+// ASCII characters that the profile allows (see Profile.Charset), so that
+// Rename fails rather than produce other names.  Unless the mode is "fail",
+// the rule "strict-ascii" is appended to the sanitizers to write the
+// remaining runes by their code points (see StrictASCIIModes).  Collision
+// suffixes like "_01" and truncation keep to the charset.
+func (config *Config) ApplyStrictASCII(mode string, profile Profile) error {
+	if mode != "fail" {
+		rule, err := NewStrictASCIIRule(mode, profile.Charset)
+		if err != nil {
+			if !slices.Contains(StrictASCIIModes, mode) {
+				err = fmt.Errorf("unknown strict ASCII mode '%s' (must be one of: %s)", mode, strings.Join(StrictASCIIModes, ", "))
+			}
+			return err
+		}
+		config.Sanitizer = withRule(config.sanitizer(), rule)
+		for path, sanitizer := range config.Subtrees {
+			config.Subtrees[path] = withRule(sanitizer, rule)
+		}
+	}
+	config.Charset = StrictASCIICharset(profile.Charset)
+	return nil
+}
+
+// ApplyReversible configures sanitizing such that the original names can
+// always be reconstructed: names are only encoded reversibly (see
+// NewReversibleRule), names that are not valid UTF-8 are encoded as they are
+// rather than decoded, and Rename fails for names that it would have to
+// truncate or to append a suffix to.
+func (config *Config) ApplyReversible(profile Profile) error {
+	rule, err := NewReversibleRule(profile.Charset)
+	if err != nil {
+		return err
+	}
+	sanitizer := NewSanitizer(rule)
+	sanitizer.SetAllowlist(config.sanitizer().Allowlist())
+	config.Sanitizer = sanitizer
+	config.Subtrees = nil
+	config.Decoder = nil
+	config.Reversible = true
+	return nil
+}
+
+// ApplyNormalization writes sanitized names in the given normalization form
+// (see NormalizationForms) by appending the rule "normalize-nfc" or
+// "normalize-nfd" to the sanitizers.  If only is true, the sanitizers only
+// normalize names rather than apply any other rule, for names that are fine
+// except for their mixed normalization forms.
+func (config *Config) ApplyNormalization(form string, only bool) error {
+	rule, err := NewNormalizationRule(form)
+	if err != nil {
+		return err
+	}
+	if only {
+		sanitizer := NewSanitizer(rule)
+		sanitizer.SetAllowlist(config.sanitizer().Allowlist())
+		config.Sanitizer = sanitizer
+		config.Subtrees = nil
+		return nil
+	}
+	config.Sanitizer = withRule(config.sanitizer(), rule)
+	for path, sanitizer := range config.Subtrees {
+		config.Subtrees[path] = withRule(sanitizer, rule)
+	}
+	return nil
+}
+
+// withRule returns a copy of the sanitizer with the rule appended.
+func withRule(sanitizer *Sanitizer, rule Rule) *Sanitizer {
+	s := NewSanitizer(append(sanitizer.Rules(), rule)...)
+	s.SetAllowlist(sanitizer.Allowlist())
+	return s
+}
+
+func (config Config) sanitizer() *Sanitizer {
+	if config.Sanitizer == nil {
+		return defaultSanitizer
+	}
+	return config.Sanitizer
+}
+
+// forNode returns the configuration for the node, which uses the sanitizer of
+// the node's subtree, if any.
+func (config Config) forNode(node FsNode) Config {
+	path := filepath.Clean(node.OriginalPath())
+	for subtree, sanitizer := range config.Subtrees {
+		if filepath.Clean(subtree) == path {
+			config.Sanitizer = sanitizer
+		}
+	}
+	return config
 }
 
 var DefaultSkipDirectories = map[string]bool{
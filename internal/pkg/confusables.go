@@ -0,0 +1,166 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+//go:embed dict/confusables.txt
+var confusablesData []byte
+
+// NewConfusablesRule returns the rule "fold-confusables", which folds letters
+// of other scripts that look like Latin letters, like the Cyrillic 'а'
+// (U+0430) or the Greek 'Ο' (U+039F), to those Latin letters in words that mix
+// them with Latin letters, e.g. "Pаris" with a Cyrillic 'а'.  Such words
+// usually come from copy-paste or spam, and transliterating them letter by
+// letter gets them wrong, e.g. "Сat" with a Cyrillic 'С' as "Sat".
+//
+// Only words that can be written in Latin letters entirely are folded.  Words
+// in other scripts, like "Москва", and mixed words with letters that do not
+// look like Latin letters are left to the transliteration rules.  Letters
+// look like Latin letters if they have the same prototype in the Unicode
+// confusables data (UTS #39) as a Latin letter, and they are folded to the
+// Latin letter of the same case, e.g. the Cyrillic 'І' to 'I' rather than 'l'.
+func NewConfusablesRule() (Rule, error) {
+	prototypes, err := loadConfusables()
+	if err != nil {
+		return nil, err
+	}
+	prototype := func(c rune) rune {
+		if p, ok := prototypes[c]; ok {
+			return p
+		}
+		return c
+	}
+	latin := map[rune]rune{}
+	for c := range prototypes {
+		if unicode.Is(unicode.Latin, c) {
+			continue
+		}
+		for _, l := range "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz" {
+			if prototype(l) != prototype(c) {
+				continue
+			}
+			if _, ok := latin[c]; !ok || unicode.IsUpper(l) == unicode.IsUpper(c) {
+				latin[c] = l
+			}
+		}
+	}
+	return confusablesRule{latin: latin}, nil
+}
+
+var foldConfusables, _ = NewConfusablesRule()
+
+// loadConfusables loads the embedded confusables data, which maps characters
+// to their prototypes.
+func loadConfusables() (map[rune]rune, error) {
+	prototypes := map[rune]rune{}
+	scanner := bufio.NewScanner(bytes.NewReader(confusablesData))
+	for line := 1; scanner.Scan(); line++ {
+		text, _, _ := strings.Cut(scanner.Text(), "#")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		fields := strings.Split(text, ";")
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("dict/confusables.txt: line %d: expected a source and a prototype", line)
+		}
+		var codePoints [2]rune
+		for i := range codePoints {
+			c, err := strconv.ParseUint(strings.TrimSpace(fields[i]), 16, 32)
+			if err != nil {
+				return nil, fmt.Errorf("dict/confusables.txt: line %d: %w", line, err)
+			}
+			codePoints[i] = rune(c)
+		}
+		prototypes[codePoints[0]] = codePoints[1]
+	}
+	return prototypes, scanner.Err()
+}
+
+type confusablesRule struct {
+	// latin maps letters of other scripts to the Latin letters that they
+	// look like.
+	latin map[rune]rune
+}
+
+func (r confusablesRule) Name() string { return "fold-confusables" }
+
+func (r confusablesRule) Apply(name string) string {
+	var b strings.Builder
+	for _, word := range splitScriptWords(name) {
+		if r.foldable(word) {
+			word = strings.Map(r.fold, word)
+		}
+		b.WriteString(word)
+	}
+	return b.String()
+}
+
+func (r confusablesRule) fold(c rune) rune {
+	if l, ok := r.latin[c]; ok {
+		return l
+	}
+	return c
+}
+
+// foldable reports whether the word mixes Latin letters with letters of
+// other scripts that all look like Latin letters.
+func (r confusablesRule) foldable(word string) bool {
+	hasLatin, hasOther := false, false
+	for _, c := range word {
+		switch {
+		case !unicode.IsLetter(c):
+		case unicode.Is(unicode.Latin, c):
+			hasLatin = true
+		default:
+			if _, ok := r.latin[c]; !ok {
+				return false
+			}
+			hasOther = true
+		}
+	}
+	return hasLatin && hasOther
+}
+
+// Explain lists every letter that the rule folded, and the word it is in.
+func (r confusablesRule) Explain(before, after string) string {
+	var folded []string
+	for _, word := range splitScriptWords(before) {
+		if !r.foldable(word) {
+			continue
+		}
+		for _, c := range word {
+			if l := r.fold(c); l != c {
+				folded = append(folded, fmt.Sprintf("'%c' (U+%04X) as '%c' in %q", c, c, l, word))
+			}
+		}
+	}
+	return "folded letters that look like Latin letters: " + strings.Join(folded, ", ")
+}
+
+// splitScriptWords splits the name into words of letters, marks, and digits,
+// and the runs of other characters between them.
+func splitScriptWords(name string) []string {
+	inWord := func(c rune) bool {
+		return unicode.IsLetter(c) || unicode.IsMark(c) || unicode.IsDigit(c)
+	}
+	var words []string
+	start := 0
+	for i, c := range name {
+		if first, _ := utf8.DecodeRuneInString(name[start:]); i > start && inWord(c) != inWord(first) {
+			words = append(words, name[start:i])
+			start = i
+		}
+	}
+	if start < len(name) {
+		words = append(words, name[start:])
+	}
+	return words
+}
@@ -0,0 +1,48 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfusablesRule(t *testing.T) {
+	rule, err := NewConfusablesRule()
+	assert.NoError(t, err)
+	assert.Equal(t, "fold-confusables", rule.Name())
+
+	// Cyrillic 'а' (U+0430), 'е' (U+0435), and 'о' (U+043E)
+	assert.Equal(t, "Paris.txt", rule.Apply("Pаris.txt"))
+	assert.Equal(t, "Windows10 Home.iso", rule.Apply("Windоws10 Hоmе.iso"))
+	// Greek 'Ο' (U+039F) and Cyrillic 'С' (U+0421), 'І' (U+0406)
+	assert.Equal(t, "OK Cat IBM", rule.Apply("ΟK Сat ІBM"))
+	// The Cyrillic 'І' looks like 'l', too, but is folded to the letter of
+	// the same case
+	assert.Equal(t, "Ili", rule.Apply("Іli"))
+
+	assert.Equal(t, "Москва.mp3", rule.Apply("Москва.mp3"), "words in other scripts are left alone")
+	assert.Equal(t, "Ἀθῆναι", rule.Apply("Ἀθῆναι"))
+	assert.Equal(t, "ΟΡΕΝ", rule.Apply("ΟΡΕΝ"), "words without Latin letters are left alone")
+	assert.Equal(t, "Moskвa", rule.Apply("Moskвa"), "'в' does not look like a Latin letter")
+	assert.Equal(t, "Café", rule.Apply("Café"))
+}
+
+func TestConfusablesRuleExplains(t *testing.T) {
+	rule, _ := NewConfusablesRule()
+	explainer := rule.(Explainer)
+	before := "Pаris Сat"
+	assert.Equal(t,
+		`folded letters that look like Latin letters: 'а' (U+0430) as 'a' in "Pаris", 'С' (U+0421) as 'C' in "Сat"`,
+		explainer.Explain(before, rule.Apply(before)))
+}
+
+func TestSanitizeFoldsConfusables(t *testing.T) {
+	// Without folding, the Cyrillic 'С' and 'х' are transliterated as "S"
+	// and "kh"
+	assert.Equal(t, "Cat xbox Moskva.mp3", Sanitize("Сat хbox Москва.mp3"))
+}
+
+func TestSplitScriptWords(t *testing.T) {
+	assert.Equal(t, []string{"Track", " ", "01", " - ", "Pаris", "."}, splitScriptWords("Track 01 - Pаris."))
+	assert.Nil(t, splitScriptWords(""))
+}
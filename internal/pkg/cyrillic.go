@@ -0,0 +1,257 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// CyrillicStandard is a system for transliterating Cyrillic into Latin
+// letters.  Some standards transliterate the same letter differently
+// depending on the language, e.g. the Ukrainian 'и' is "y" in BGN/PCGN but
+// the Russian 'и' is "i".
+type CyrillicStandard struct {
+	Name        string
+	Description string
+	// base maps the lowercase letters of all supported languages.  It uses
+	// the conventions of Russian where languages disagree.
+	base map[rune]string
+	// languages lists the supported languages by ISO 639-1 code.  Their
+	// letters override base.
+	languages map[string]cyrillicTable
+}
+
+// cyrillicTable holds the language-specific part of a standard.  All keys are
+// lowercase; uppercase letters are transliterated by uppercasing the result.
+type cyrillicTable struct {
+	letters map[rune]string
+	// initial overrides letters at the beginning of a word, and after any of
+	// the letters in initialAfter.
+	initial      map[rune]string
+	initialAfter string
+	// sequences maps pairs of letters that are transliterated together.
+	sequences map[string]string
+	// finals maps pairs of letters that are transliterated together at the
+	// end of a word.
+	finals map[string]string
+}
+
+// CyrillicLanguages lists the languages that can be passed to
+// NewCyrillicRule.
+var CyrillicLanguages = []string{"ru", "uk", "bg", "sr"}
+
+// CyrillicStandards lists the supported transliteration standards.  Letters
+// with diacritics, like the 'ž' of ISO 9, are reduced to ASCII by the later
+// rules "remove-nonspacing-marks" and "transliterate-latin".
+var CyrillicStandards = []CyrillicStandard{
+	{
+		Name:        "bgn",
+		Description: "BGN/PCGN romanization, which is common in English-language maps and media",
+		base: map[rune]string{
+			'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "ë",
+			'ж': "zh", 'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m",
+			'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+			'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch",
+			'ъ': "ˮ", 'ы': "y", 'ь': "ʼ", 'э': "e", 'ю': "yu", 'я': "ya",
+			'ґ': "g", 'є': "ye", 'і': "i", 'ї': "yi", 'ў': "w",
+			'ђ': "đ", 'ј': "j", 'љ': "lj", 'њ': "nj", 'ћ': "ć", 'џ': "dž",
+			'ѓ': "gj", 'ќ': "kj", 'ѕ': "dz",
+		},
+		languages: map[string]cyrillicTable{
+			"ru": {
+				initial:      map[rune]string{'е': "ye", 'ё': "yë"},
+				initialAfter: "аеёиоуыэюяйъь",
+			},
+			// The Ukrainian national system of 2010, which BGN/PCGN adopted.
+			"uk": {
+				letters: map[rune]string{
+					'г': "h", 'є': "ie", 'и': "y", 'ї': "i", 'й': "i", 'ь': "",
+					'ю': "iu", 'я': "ia", '’': "", 'ʼ': "",
+				},
+				initial:   map[rune]string{'є': "ye", 'ї': "yi", 'й': "y", 'ю': "yu", 'я': "ya"},
+				sequences: map[string]string{"зг": "zgh"},
+			},
+			// The Bulgarian Streamlined System of 2009, which BGN/PCGN
+			// adopted.
+			"bg": {
+				letters: map[rune]string{'х': "h", 'щ': "sht", 'ъ': "a", 'ь': "y"},
+				finals:  map[string]string{"ия": "ia"},
+			},
+			// The Serbian Latin alphabet.
+			"sr": {
+				letters: map[rune]string{'ж': "ž", 'х': "h", 'ц': "c", 'ч': "č", 'ш': "š"},
+			},
+		},
+	},
+	{
+		Name:        "iso9",
+		Description: "ISO 9:1995 (identical to GOST 7.79-2000 System A), which maps each letter to a unique Latin letter",
+		base: map[rune]string{
+			'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "ë",
+			'ж': "ž", 'з': "z", 'и': "i", 'й': "j", 'к': "k", 'л': "l", 'м': "m",
+			'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+			'ф': "f", 'х': "h", 'ц': "c", 'ч': "č", 'ш': "š", 'щ': "ŝ",
+			'ъ': "ʺ", 'ы': "y", 'ь': "ʹ", 'э': "è", 'ю': "û", 'я': "â",
+			'ґ': "g̀", 'є': "ê", 'і': "ì", 'ї': "ï", 'ў': "ŭ",
+			'ђ': "đ", 'ј': "ǰ", 'љ': "l̂", 'њ': "n̂", 'ћ': "ć", 'џ': "d̂",
+			'ѓ': "ǵ", 'ќ': "ḱ", 'ѕ': "ẑ",
+		},
+		// ISO 9 is language-independent by design.
+		languages: map[string]cyrillicTable{"ru": {}, "uk": {}, "bg": {}, "sr": {}},
+	},
+	{
+		Name:        "gost",
+		Description: "GOST 7.79-2000 System B, which uses ASCII only",
+		base: map[rune]string{
+			'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo",
+			'ж': "zh", 'з': "z", 'и': "i", 'й': "j", 'к': "k", 'л': "l", 'м': "m",
+			'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+			'ф': "f", 'х': "x", 'ц': "cz", 'ч': "ch", 'ш': "sh", 'щ': "shh",
+			'ъ': "``", 'ы': "y`", 'ь': "`", 'э': "e`", 'ю': "yu", 'я': "ya",
+			'ґ': "g`", 'є': "ye", 'і': "i", 'ї': "yi", 'ў': "u`",
+			// GOST 7.79 does not cover 'ђ' and 'ћ'.  We follow its convention
+			// of marking modified letters with a backtick.
+			'ђ': "d`", 'ј': "j", 'љ': "l`", 'њ': "n`", 'ћ': "c`", 'џ': "dh",
+			'ѓ': "g`", 'ќ': "k`", 'ѕ': "z`",
+		},
+		languages: map[string]cyrillicTable{
+			"ru": {},
+			"uk": {letters: map[rune]string{'и': "y`", '’': "'"}},
+			"bg": {letters: map[rune]string{'щ': "sht", 'ъ': "a`"}},
+			"sr": {},
+		},
+	},
+	{
+		Name:        "scientific",
+		Description: "scientific transliteration, which is common in linguistics and Slavic studies",
+		base: map[rune]string{
+			'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "ë",
+			'ж': "ž", 'з': "z", 'и': "i", 'й': "j", 'к': "k", 'л': "l", 'м': "m",
+			'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+			'ф': "f", 'х': "x", 'ц': "c", 'ч': "č", 'ш': "š", 'щ': "šč",
+			'ъ': "ʺ", 'ы': "y", 'ь': "ʹ", 'э': "è", 'ю': "ju", 'я': "ja",
+			'ґ': "g", 'є': "je", 'і': "i", 'ї': "ji", 'ў': "ŭ",
+			'ђ': "đ", 'ј': "j", 'љ': "lj", 'њ': "nj", 'ћ': "ć", 'џ': "dž",
+			'ѓ': "ǵ", 'ќ': "ḱ", 'ѕ': "dz",
+		},
+		languages: map[string]cyrillicTable{
+			"ru": {},
+			"uk": {letters: map[rune]string{'г': "h", 'и': "y"}},
+			"bg": {letters: map[rune]string{'щ': "št", 'ъ': "ă"}},
+			"sr": {letters: map[rune]string{'х': "h"}},
+		},
+	},
+}
+
+// CyrillicStandardNames returns the comma-separated names of the supported
+// transliteration standards.
+func CyrillicStandardNames() string {
+	var names []string
+	for _, s := range CyrillicStandards {
+		names = append(names, s.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// transliterateCyrillic is the default Cyrillic rule: BGN/PCGN for Russian.
+var transliterateCyrillic, _ = NewCyrillicRule("bgn", "ru")
+
+// NewCyrillicRule returns a rule that transliterates Cyrillic letters
+// according to the named standard (see CyrillicStandards) and language (see
+// CyrillicLanguages).
+//
+// The rule must run before "decompose-nfd", which would otherwise turn
+// letters like 'й' into a base letter and a combining mark.
+func NewCyrillicRule(standard, language string) (Rule, error) {
+	for _, s := range CyrillicStandards {
+		if s.Name != standard {
+			continue
+		}
+		lang, ok := s.languages[language]
+		if !ok {
+			return nil, fmt.Errorf("unknown Cyrillic language '%s' (must be one of: %s)",
+				language, strings.Join(CyrillicLanguages, ", "))
+		}
+		table := cyrillicTable{
+			letters:      map[rune]string{},
+			initial:      lang.initial,
+			initialAfter: lang.initialAfter,
+			sequences:    map[string]string{},
+			finals:       lang.finals,
+		}
+		for c, latin := range s.base {
+			table.letters[c] = latin
+		}
+		for c, latin := range lang.letters {
+			table.letters[c] = latin
+		}
+		for pair, latin := range lang.sequences {
+			table.sequences[pair] = latin
+		}
+		if s.Name == "gost" {
+			addGOSTSequences(table)
+		}
+		return cyrillicRule{table: table}, nil
+	}
+	return nil, fmt.Errorf("unknown Cyrillic standard '%s' (must be one of: %s)", standard, CyrillicStandardNames())
+}
+
+// addGOSTSequences adds the rule of GOST 7.79 System B that 'ц' becomes "c"
+// rather than "cz" before the Latin letters i, e, y, and j.
+func addGOSTSequences(table cyrillicTable) {
+	for c, latin := range table.letters {
+		if latin != "" && strings.ContainsRune("ieyj", rune(latin[0])) {
+			table.sequences[string([]rune{'ц', c})] = "c" + latin
+		}
+	}
+}
+
+type cyrillicRule struct {
+	table cyrillicTable
+}
+
+func (r cyrillicRule) Name() string { return "transliterate-cyrillic" }
+
+func (r cyrillicRule) Apply(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i := 0; i < len(runes); {
+		latin, n, ok := r.lookup(runes, i)
+		if !ok {
+			b.WriteRune(runes[i])
+			i++
+			continue
+		}
+		b.WriteString(matchCase(latin, runes, i, n))
+		i += n
+	}
+	return b.String()
+}
+
+// lookup returns the transliteration of the letter (or pair of letters) at
+// runes[i] and the number of runes it covers.
+func (r cyrillicRule) lookup(runes []rune, i int) (string, int, bool) {
+	c := unicode.ToLower(runes[i])
+	if i+1 < len(runes) {
+		pair := string([]rune{c, unicode.ToLower(runes[i+1])})
+		if latin, ok := r.table.finals[pair]; ok && (i+2 == len(runes) || !isWordRune(runes[i+2])) {
+			return latin, 2, true
+		}
+		if latin, ok := r.table.sequences[pair]; ok {
+			return latin, 2, true
+		}
+	}
+	if latin, ok := r.table.initial[c]; ok {
+		if i == 0 || !isWordRune(runes[i-1]) || strings.ContainsRune(r.table.initialAfter, unicode.ToLower(runes[i-1])) {
+			return latin, 1, true
+		}
+	}
+	latin, ok := r.table.letters[c]
+	return latin, 1, ok
+}
+
+// isWordRune reports whether the rune is part of a word.  Apostrophes are, as
+// in the Ukrainian "Знам’янка".
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || r == '\'' || r == '’' || r == 'ʼ'
+}
@@ -0,0 +1,189 @@
+package internal
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Examples from the BGN/PCGN romanization tables, including the official
+// examples of the Ukrainian national system and the Bulgarian Streamlined
+// System.
+func TestCyrillicBGN(t *testing.T) {
+	russian, err := NewCyrillicRule("bgn", "ru")
+	require.NoError(t, err)
+	assert.Equal(t, "Moskva", russian.Apply("Москва"))
+	assert.Equal(t, "Shchedrin", russian.Apply("Щедрин"))
+	assert.Equal(t, "Yuriy Gagarin", russian.Apply("Юрий Гагарин"))
+	assert.Equal(t, "Yelʼtsin", russian.Apply("Ельцин"))
+	assert.Equal(t, "Khrushchëv", russian.Apply("Хрущёв"))
+	assert.Equal(t, "podˮyezd", russian.Apply("подъезд"))
+	assert.Equal(t, "Mayyerovo", russian.Apply("Майерово"))
+	assert.Equal(t, "ANDREY.txt", russian.Apply("АНДРЕЙ.txt"))
+
+	ukrainian, err := NewCyrillicRule("bgn", "uk")
+	require.NoError(t, err)
+	assert.Equal(t, "Kyiv", ukrainian.Apply("Київ"))
+	assert.Equal(t, "Zghurivka", ukrainian.Apply("Згурівка"))
+	assert.Equal(t, "Yahotyn", ukrainian.Apply("Яготин"))
+	assert.Equal(t, "Yenakiieve", ukrainian.Apply("Єнакієве"))
+	assert.Equal(t, "Feodosiia", ukrainian.Apply("Феодосія"))
+	assert.Equal(t, "Yurii", ukrainian.Apply("Юрій"))
+	assert.Equal(t, "Mariupol", ukrainian.Apply("Маріуполь"))
+	assert.Equal(t, "Znamianka", ukrainian.Apply("Знам’янка"))
+
+	bulgarian, err := NewCyrillicRule("bgn", "bg")
+	require.NoError(t, err)
+	assert.Equal(t, "Sofia", bulgarian.Apply("София"))
+	assert.Equal(t, "Tarnovo", bulgarian.Apply("Търново"))
+	assert.Equal(t, "Shtastlivtsi", bulgarian.Apply("Щастливци"))
+	assert.Equal(t, "Himia 1", bulgarian.Apply("Химия 1"))
+
+	serbian, err := NewCyrillicRule("bgn", "sr")
+	require.NoError(t, err)
+	assert.Equal(t, "Novak Đoković", serbian.Apply("Новак Ђоковић"))
+	assert.Equal(t, "Džakovica", serbian.Apply("Џаковица"))
+	assert.Equal(t, "Ljubljana", serbian.Apply("Љубљана"))
+	assert.Equal(t, "Ćuprija", serbian.Apply("Ћуприја"))
+}
+
+func TestCyrillicGOST(t *testing.T) {
+	russian, err := NewCyrillicRule("gost", "ru")
+	require.NoError(t, err)
+	assert.Equal(t, "Shhuka", russian.Apply("Щука"))
+	assert.Equal(t, "Cirk", russian.Apply("Цирк"))
+	assert.Equal(t, "Czar`", russian.Apply("Царь"))
+	assert.Equal(t, "CIRK", russian.Apply("ЦИРК"))
+	assert.Equal(t, "ob``yom", russian.Apply("объём"))
+	assert.Equal(t, "E`xo", russian.Apply("Эхо"))
+	assert.Equal(t, "vy`xod", russian.Apply("выход"))
+
+	ukrainian, err := NewCyrillicRule("gost", "uk")
+	require.NoError(t, err)
+	assert.Equal(t, "Ky`yiv", ukrainian.Apply("Київ"))
+
+	bulgarian, err := NewCyrillicRule("gost", "bg")
+	require.NoError(t, err)
+	assert.Equal(t, "Shtastlivci", bulgarian.Apply("Щастливци"))
+}
+
+func TestCyrillicScientific(t *testing.T) {
+	russian, err := NewCyrillicRule("scientific", "ru")
+	require.NoError(t, err)
+	assert.Equal(t, "Ščuka", russian.Apply("Щука"))
+	assert.Equal(t, "Xruščëv", russian.Apply("Хрущёв"))
+	assert.Equal(t, "Jurij", russian.Apply("Юрий"))
+
+	ukrainian, err := NewCyrillicRule("scientific", "uk")
+	require.NoError(t, err)
+	assert.Equal(t, "Kyjiv", ukrainian.Apply("Київ"))
+	assert.Equal(t, "Hryhorij", ukrainian.Apply("Григорій"))
+
+	bulgarian, err := NewCyrillicRule("scientific", "bg")
+	require.NoError(t, err)
+	assert.Equal(t, "Tărnovo", bulgarian.Apply("Търново"))
+
+	serbian, err := NewCyrillicRule("scientific", "sr")
+	require.NoError(t, err)
+	assert.Equal(t, "Đorđe", serbian.Apply("Ђорђе"))
+}
+
+func TestCyrillicISO9(t *testing.T) {
+	russian, err := NewCyrillicRule("iso9", "ru")
+	require.NoError(t, err)
+	assert.Equal(t, "Ŝëlkovo", russian.Apply("Щёлково"))
+
+	ukrainian, err := NewCyrillicRule("iso9", "uk")
+	require.NoError(t, err)
+	assert.Equal(t, "Kiïv", ukrainian.Apply("Київ"))
+
+	serbian, err := NewCyrillicRule("iso9", "sr")
+	require.NoError(t, err)
+	assert.Equal(t, "Đorđe", serbian.Apply("Ђорђе"))
+	assert.Equal(t, "L̂ubl̂ana", serbian.Apply("Љубљана"))
+}
+
+// ISO 9 maps each Cyrillic letter to a unique Latin letter, so that a
+// transliteration can be reversed.
+func TestCyrillicISO9RoundTrip(t *testing.T) {
+	var iso9 CyrillicStandard
+	for _, s := range CyrillicStandards {
+		if s.Name == "iso9" {
+			iso9 = s
+		}
+	}
+	reverse := map[string]rune{}
+	var latins []string
+	for c, latin := range iso9.base {
+		other, ok := reverse[latin]
+		assert.False(t, ok, "both %q and %q map to %q", c, other, latin)
+		reverse[latin] = c
+		latins = append(latins, latin)
+	}
+	// Longest match first, so that "g̀" is not read as "g".
+	sort.Slice(latins, func(i, j int) bool { return len(latins[i]) > len(latins[j]) })
+
+	var alphabet strings.Builder
+	for c := range iso9.base {
+		alphabet.WriteRune(c)
+	}
+	rule, err := NewCyrillicRule("iso9", "ru")
+	require.NoError(t, err)
+	for _, original := range []string{alphabet.String(), "щёлково", "київ", "ђорђе", "љубљана"} {
+		transliterated := rule.Apply(original)
+		var restored strings.Builder
+		for rest := transliterated; rest != ""; {
+			found := false
+			for _, latin := range latins {
+				if strings.HasPrefix(rest, latin) {
+					restored.WriteRune(reverse[latin])
+					rest = rest[len(latin):]
+					found = true
+					break
+				}
+			}
+			if !assert.True(t, found, "cannot reverse %q", rest) {
+				break
+			}
+		}
+		assert.Equal(t, original, restored.String())
+	}
+}
+
+func TestCyrillicStandardsCoverAllLanguages(t *testing.T) {
+	for _, s := range CyrillicStandards {
+		for _, language := range CyrillicLanguages {
+			_, err := NewCyrillicRule(s.Name, language)
+			assert.NoError(t, err, "%s/%s", s.Name, language)
+		}
+	}
+}
+
+func TestNewCyrillicRuleErrors(t *testing.T) {
+	_, err := NewCyrillicRule("din", "ru")
+	assert.EqualError(t, err, "unknown Cyrillic standard 'din' (must be one of: bgn, iso9, gost, scientific)")
+	_, err = NewCyrillicRule("bgn", "be")
+	assert.EqualError(t, err, "unknown Cyrillic language 'be' (must be one of: ru, uk, bg, sr)")
+}
+
+func TestSanitizeTransliteratesCyrillic(t *testing.T) {
+	assert.Equal(t, "Khrushchev", Sanitize("Хрущёв"))
+	assert.Equal(t, "Yuriy Gagarin.mp3", Sanitize("Юрий Гагарин.mp3"))
+	assert.Equal(t, "Yel'tsin", Sanitize("Ельцин"))
+	assert.Equal(t, "pod'yezd", Sanitize("подъезд"))
+	for _, s := range CyrillicStandards {
+		for _, language := range CyrillicLanguages {
+			rule, _ := NewCyrillicRule(s.Name, language)
+			sanitizer := NewSanitizer(DefaultRules()...)
+			assert.NoError(t, sanitizer.Replace("transliterate-cyrillic", rule))
+			for c := range s.base {
+				name := string(c) + strings.ToUpper(string(c))
+				assert.True(t, isPrintableASCII(sanitizer.Sanitize(name)),
+					"%s/%s: %q became %q", s.Name, language, name, sanitizer.Sanitize(name))
+			}
+		}
+	}
+}
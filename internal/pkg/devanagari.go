@@ -0,0 +1,113 @@
+package internal
+
+import (
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// devanagariConsonants maps the Devanagari consonants without their inherent
+// vowel, in the spirit of the Hunterian transliteration without diacritics
+// that Indian road signs and maps use.
+var devanagariConsonants = map[rune]string{
+	'क': "k", 'ख': "kh", 'ग': "g", 'घ': "gh", 'ङ': "n",
+	'च': "ch", 'छ': "chh", 'ज': "j", 'झ': "jh", 'ञ': "n",
+	'ट': "t", 'ठ': "th", 'ड': "d", 'ढ': "dh", 'ण': "n",
+	'त': "t", 'थ': "th", 'द': "d", 'ध': "dh", 'न': "n",
+	'प': "p", 'फ': "ph", 'ब': "b", 'भ': "bh", 'म': "m",
+	'य': "y", 'र': "r", 'ल': "l", 'ळ': "l", 'व': "v",
+	'श': "sh", 'ष': "sh", 'स': "s", 'ह': "h",
+}
+
+// devanagariNukta maps the consonants with a nukta, which mostly write sounds
+// of Persian and English loanwords.
+var devanagariNukta = map[rune]string{
+	'क': "q", 'ख': "kh", 'ग': "gh", 'ज': "z", 'ड': "r", 'ढ': "rh", 'फ': "f",
+	'य': "y", 'न': "n", 'र': "r", 'ळ': "l",
+}
+
+// devanagariVowels maps the independent vowels.
+var devanagariVowels = map[rune]string{
+	'अ': "a", 'आ': "a", 'इ': "i", 'ई': "i", 'उ': "u", 'ऊ': "u", 'ऋ': "ri",
+	'ॠ': "ri", 'ऌ': "li", 'ए': "e", 'ऐ': "ai", 'ओ': "o", 'औ': "au", 'ऍ': "e",
+	'ऑ': "o", 'ऎ': "e", 'ऒ': "o",
+}
+
+// devanagariMatras maps the dependent vowel signs, which replace the inherent
+// vowel of the preceding consonant.
+var devanagariMatras = map[rune]string{
+	'ा': "a", 'ि': "i", 'ी': "i", 'ु': "u", 'ू': "u", 'ृ': "ri", 'ॄ': "ri",
+	'ॢ': "li", 'े': "e", 'ै': "ai", 'ो': "o", 'ौ': "au", 'ॅ': "e", 'ॉ': "o",
+	'ॆ': "e", 'ॊ': "o",
+}
+
+const (
+	devanagariVirama    = '्'
+	devanagariNuktaSign = '़'
+)
+
+// transliterateDevanagari romanizes a run of Devanagari, as used for Hindi,
+// Marathi, and Nepali.  The inherent vowel "a" of the last consonant of a word
+// is dropped, as it is in Hindi, so that "भारत" becomes "bharat" rather than
+// "bharata".
+func transliterateDevanagari(run []rune) string {
+	runes := []rune(norm.NFD.String(string(run)))
+	var b strings.Builder
+	syllables := 0
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		if latin, ok := devanagariConsonants[c]; ok {
+			if i+1 < len(runes) && runes[i+1] == devanagariNuktaSign {
+				latin = devanagariNukta[c]
+				i++
+			}
+			b.WriteString(latin)
+			syllables++
+			if i+1 < len(runes) {
+				if _, ok := devanagariMatras[runes[i+1]]; ok || runes[i+1] == devanagariVirama {
+					continue
+				}
+			}
+			// The inherent vowel is kept in words of a single syllable,
+			// as in "न" ("na"), and after a conjunct, as in "कृष्ण".
+			if i+1 == len(runes) && syllables > 1 && runes[i-1] != devanagariVirama {
+				continue
+			}
+			b.WriteString("a")
+			continue
+		}
+		if latin, ok := devanagariVowels[c]; ok {
+			b.WriteString(latin)
+			syllables++
+			continue
+		}
+		if latin, ok := devanagariMatras[c]; ok {
+			b.WriteString(latin)
+			continue
+		}
+		switch c {
+		case devanagariVirama, devanagariNuktaSign:
+		case 'ं': // anusvara, which is "m" before labials, as in "मुंबई"
+			if i+1 < len(runes) && strings.ContainsRune("पफबभम", runes[i+1]) {
+				b.WriteString("m")
+			} else {
+				b.WriteString("n")
+			}
+		case 'ँ': // chandrabindu
+			b.WriteString("n")
+		case 'ः': // visarga
+			b.WriteString("h")
+		case 'ॐ':
+			b.WriteString("om")
+		case 'ऽ': // avagraha
+			b.WriteString("'")
+		default:
+			if latin, ok := transliterateDigit(c); ok {
+				b.WriteString(latin)
+			} else {
+				b.WriteRune(c)
+			}
+		}
+	}
+	return b.String()
+}
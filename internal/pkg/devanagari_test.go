@@ -0,0 +1,28 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/unicode/norm"
+)
+
+func TestTransliterateDevanagari(t *testing.T) {
+	examples := map[string]string{
+		"नमस्ते":   "namaste",
+		"भारत":     "bharat",
+		"हिन्दी":   "hindi",
+		"मुंबई":    "mumbai",
+		"दिल्ली":   "dilli",
+		"गंगा":     "ganga",
+		"कृष्ण":    "krishna",
+		"न":        "na",
+		"ज़िन्दगी": "zindagi",
+		"ॐ":        "om",
+		"२०२४":     "2024",
+	}
+	for devanagari, latin := range examples {
+		assert.Equal(t, latin, transliterateDevanagari([]rune(devanagari)), devanagari)
+		assert.Equal(t, latin, transliterateDevanagari([]rune(norm.NFC.String(devanagari))), "composed %s", devanagari)
+	}
+}
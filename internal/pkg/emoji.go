@@ -0,0 +1,203 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// EmojiModes lists the modes that can be passed to NewEmojiRule: "name"
+// replaces emoji with their CLDR short names, and "drop" removes them.
+var EmojiModes = []string{"name", "drop"}
+
+//go:embed dict/emoji.txt
+var emojiData []byte
+
+const (
+	zeroWidthJoiner = '\u200D'
+	emojiVS         = '\uFE0F' // variation selector 16, which requests emoji presentation
+	textVS          = '\uFE0E' // variation selector 15, which requests text presentation
+)
+
+// NewEmojiRule returns a rule that replaces emoji with their CLDR short names
+// in kebab case, e.g. "🏖️" with "beach-with-umbrella", or drops them (see
+// EmojiModes).  Sequences of several code points, like ZWJ sequences ("👨‍👩‍👧"
+// is "family-man-woman-girl"), emoji with skin tones, and flags, are replaced
+// as a whole.  Adjacent emoji are separated from each other, and from
+// adjacent letters and digits, by '_'.  Names that consist of nothing but
+// emoji keep their short names even in mode "drop".
+//
+// Symbols that are text by default, like '©' and '☀', are only replaced if they
+// are followed by the variation selector U+FE0F, which requests an emoji.
+func NewEmojiRule(mode string) (Rule, error) {
+	if mode != "name" && mode != "drop" {
+		return nil, fmt.Errorf("unknown emoji mode '%s' (must be one of: %s)", mode, strings.Join(EmojiModes, ", "))
+	}
+	table, err := loadEmojiTable()
+	if err != nil {
+		return nil, err
+	}
+	return emojiRule{table: table, drop: mode == "drop"}, nil
+}
+
+// emojiTable maps emoji sequences to their short names.
+type emojiTable struct {
+	names map[string]string
+	// starts holds the first runes of the sequences.
+	starts map[rune]bool
+	// maxRunes is the length of the longest sequence.
+	maxRunes int
+}
+
+// loadEmojiTable loads the fully-qualified emoji of the embedded table.  Since
+// emoji in the wild often lack some of their variation selectors U+FE0F, each
+// emoji is also listed without them, unless that would leave a single code
+// point that is text by default, like '©'.  Pictographs outside the Basic
+// Multilingual Plane, like '🏖', are emoji even without U+FE0F.
+func loadEmojiTable() (emojiTable, error) {
+	t := emojiTable{names: map[string]string{}, starts: map[rune]bool{}}
+	scanner := bufio.NewScanner(bytes.NewReader(emojiData))
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		codePoints, name, ok := strings.Cut(text, ";")
+		if !ok || name == "" {
+			return t, fmt.Errorf("dict/emoji.txt: line %d: expected code points and a short name", line)
+		}
+		var sequence, stripped []rune
+		for _, field := range strings.Fields(codePoints) {
+			c, err := strconv.ParseUint(field, 16, 32)
+			if err != nil {
+				return t, fmt.Errorf("dict/emoji.txt: line %d: %w", line, err)
+			}
+			sequence = append(sequence, rune(c))
+			if rune(c) != emojiVS {
+				stripped = append(stripped, rune(c))
+			}
+		}
+		t.names[string(sequence)] = name
+		if len(stripped) > 1 || len(stripped) == len(sequence) || stripped[0] >= 0x1F000 {
+			t.names[string(stripped)] = name
+		}
+		t.starts[sequence[0]] = true
+		t.maxRunes = max(t.maxRunes, len(sequence))
+	}
+	return t, scanner.Err()
+}
+
+// lookup returns the short name of the longest emoji at the start of runes,
+// and the number of runes of the emoji.
+func (t emojiTable) lookup(runes []rune) (string, int) {
+	if !t.starts[runes[0]] {
+		return "", 0
+	}
+	for n := min(t.maxRunes, len(runes)); n > 0; n-- {
+		if name, ok := t.names[string(runes[:n])]; ok {
+			return name, n
+		}
+		if stripped := withoutEmojiVS(runes[:n]); len(stripped) > 1 && len(stripped) < n {
+			if name, ok := t.names[string(stripped)]; ok {
+				return name, n
+			}
+		}
+	}
+	return "", 0
+}
+
+func withoutEmojiVS(runes []rune) []rune {
+	var stripped []rune
+	for _, c := range runes {
+		if c != emojiVS {
+			stripped = append(stripped, c)
+		}
+	}
+	return stripped
+}
+
+type emojiRule struct {
+	table emojiTable
+	drop  bool
+}
+
+func (r emojiRule) Name() string { return "replace-emoji" }
+
+func (r emojiRule) Apply(name string) string {
+	if !r.drop {
+		return r.replace(name, false)
+	}
+	dropped := r.replace(name, true)
+	if stem := strings.TrimLeft(dropped, " "); stem == "" || stem[0] == '.' && name[0] != '.' {
+		// Names that consist of nothing but emoji keep their short
+		// names rather than become empty or hidden.
+		return r.replace(name, false)
+	}
+	return dropped
+}
+
+func (r emojiRule) replace(name string, drop bool) string {
+	runes := []rune(name)
+	var b strings.Builder
+	afterEmoji := false
+	for i := 0; i < len(runes); {
+		shortName, n := r.table.lookup(runes[i:])
+		if n == 0 {
+			c := runes[i]
+			i++
+			if afterEmoji && (c == zeroWidthJoiner || c == emojiVS || c == textVS) {
+				// leftovers of a sequence that is not in the table
+				continue
+			}
+			if afterEmoji && !drop && (unicode.IsLetter(c) || unicode.IsDigit(c)) {
+				b.WriteByte('_')
+			}
+			b.WriteRune(c)
+			afterEmoji = false
+			continue
+		}
+		i += n
+		for i < len(runes) && (runes[i] == emojiVS || runes[i] == textVS) {
+			i++
+		}
+		if drop {
+			if i == len(runes) || runes[i] == '.' || runes[i] == ' ' {
+				// "Urlaub 🌞.jpg" becomes "Urlaub.jpg" rather than "Urlaub .jpg".
+				trimmed := strings.TrimRight(b.String(), " ")
+				b.Reset()
+				b.WriteString(trimmed)
+			}
+		} else {
+			if last, _ := utf8.DecodeLastRuneInString(b.String()); afterEmoji || unicode.IsLetter(last) || unicode.IsDigit(last) {
+				b.WriteByte('_')
+			}
+			b.WriteString(kebabCase(shortName))
+		}
+		afterEmoji = true
+	}
+	return b.String()
+}
+
+// kebabCase converts a short name like "flag: Germany" to "flag-germany".
+func kebabCase(shortName string) string {
+	shortName = strings.NewReplacer("#", "number sign", "*", "asterisk").Replace(shortName)
+	var b strings.Builder
+	hyphen := false
+	for _, c := range strings.ToLower(shortName) {
+		if unicode.IsLetter(c) || unicode.IsDigit(c) {
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(c)
+			hyphen = false
+		} else {
+			hyphen = true
+		}
+	}
+	return b.String()
+}
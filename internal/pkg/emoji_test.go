@@ -0,0 +1,72 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmojiRuleReplacesEmojiWithShortNames(t *testing.T) {
+	rule, err := NewEmojiRule("name")
+	require.NoError(t, err)
+	examples := map[string]string{
+		"Urlaub 🏖️🌞":    "Urlaub beach-with-umbrella_sun-with-face",
+		"Urlaub 🏖🌞":     "Urlaub beach-with-umbrella_sun-with-face",
+		"👍🏽.jpg":        "thumbs-up-medium-skin-tone.jpg",
+		"👨‍👩‍👧 Familie": "family-man-woman-girl Familie",
+		"🇩🇪 vs 🇫🇷":      "flag-germany vs flag-france",
+		"🏴󠁧󠁢󠁥󠁮󠁧󠁿":       "flag-england",
+		"Level 1️⃣":     "Level keycap-1",
+		"#️⃣ tags":      "keycap-number-sign tags",
+		"Party🎉2024":    "Party_party-popper_2024",
+		"👁‍🗨":           "eye-in-speech-bubble",
+		"© 2024, ©️":    "© 2024, copyright",
+		"🇨🇮":            "flag-côte-d-ivoire",
+		"no emoji here": "no emoji here",
+	}
+	for name, expected := range examples {
+		assert.Equal(t, expected, rule.Apply(name), name)
+	}
+}
+
+func TestEmojiRuleDropsEmoji(t *testing.T) {
+	rule, err := NewEmojiRule("drop")
+	require.NoError(t, err)
+	assert.Equal(t, "Urlaub", rule.Apply("Urlaub 🏖️🌞"))
+	assert.Equal(t, "Urlaub.jpg", rule.Apply("Urlaub 🏖️🌞.jpg"))
+	assert.Equal(t, "Urlaub am Meer", rule.Apply("Urlaub 🏖️ am Meer"))
+	assert.Equal(t, "Party2024", rule.Apply("Party🎉2024"))
+	assert.Equal(t, " Familie", rule.Apply("👨‍👩‍👧 Familie"))
+	assert.Equal(t, "©", rule.Apply("©"))
+	assert.Equal(t, "thumbs-up-medium-skin-tone.jpg", rule.Apply("👍🏽.jpg"), "names of nothing but emoji keep their short names")
+	assert.Equal(t, ".hidden", rule.Apply(".hidden🎉"))
+}
+
+func TestEmojiRuleHandlesUnknownSequences(t *testing.T) {
+	rule, err := NewEmojiRule("name")
+	require.NoError(t, err)
+	// a ZWJ sequence that is not recommended for general interchange
+	assert.Equal(t, "cat-face_dog-face", rule.Apply("🐱‍🐶"))
+	// a skin tone on an emoji that does not take one
+	assert.Equal(t, "sun-with-face_medium-skin-tone", rule.Apply("🌞🏽"))
+}
+
+func TestSanitizeWithEmojiRule(t *testing.T) {
+	rule, err := NewEmojiRule("name")
+	assert.NoError(t, err)
+	s := NewSanitizer(append([]Rule{rule}, DefaultRules()...)...)
+	assert.Equal(t, "Urlaub beach-with-umbrella_sun-with-face.jpg", s.Sanitize("Urlaub 🏖️🌞.jpg"))
+	assert.Equal(t, "flag-cote-d-ivoire", s.Sanitize("🇨🇮"))
+}
+
+func TestNewEmojiRuleErrors(t *testing.T) {
+	_, err := NewEmojiRule("keep")
+	assert.EqualError(t, err, "unknown emoji mode 'keep' (must be one of: name, drop)")
+}
+
+func TestEmojiTableIsValid(t *testing.T) {
+	table, err := loadEmojiTable()
+	assert.NoError(t, err)
+	assert.Greater(t, len(table.names), 3000)
+}
@@ -0,0 +1,149 @@
+package internal
+
+import (
+	"fmt"
+	"sort"
+	"unicode"
+
+	"golang.org/x/text/unicode/runenames"
+)
+
+// Stage records how one stage of sanitizing a name changed it.  Stages are
+// the sanitize rules, named after the rules, followed by "truncate" and, if
+// names are limited in characters, "truncate-chars", and, if the name was
+// taken, "collision-suffix".  Names that are not valid UTF-8 are decoded in
+// a first stage like "decode-cp1252" (see ExplainName).
+type Stage struct {
+	Name   string
+	Before string
+	After  string
+	// Kept lists the characters of the allowlist that the stage would have
+	// changed without the allowlist (see Step).
+	Kept []rune
+	// Changes lists the runs of characters that the stage rewrote, in order.
+	Changes []Change
+}
+
+// Change records that a stage rewrote the characters Before as After.  Either
+// may be empty if the stage only removed or only inserted characters.
+type Change struct {
+	Before string
+	After  string
+}
+
+// ExplainName sanitizes the name of a file or, if isDir is true, of a
+// directory like Rename does, and returns all stages in order, so that users
+// can see why a name became what it became.  The output of the last stage is
+// the sanitized name.  Names that are not valid UTF-8 are decoded first, if
+// the configuration has a decoder, which adds a stage like "decode-cp1252".
+//
+// taken reports whether a sanitized name is already taken in the directory
+// of the name, in which case ExplainName appends a suffix like Rename does.
+// If taken is nil, no name is taken.  The sanitizers of subtrees are ignored
+// (see Config.Subtrees).
+func ExplainName(name string, isDir bool, config Config, taken func(string) bool) ([]Stage, error) {
+	node := FsNode{name: name, originalPath: name, isDir: isDir}
+	var decoded []Stage
+	if config.Decoder != nil {
+		decodedName, charset, err := config.Decoder.Decode(name)
+		if err != nil {
+			return nil, err
+		}
+		if decodedName != name {
+			decoded = append(decoded, Stage{Name: "decode-" + charset, Before: name, After: decodedName,
+				Changes: []Change{{Before: name, After: decodedName}}})
+			node.name = decodedName
+		}
+	}
+	for renameAttemptsThusFar := 0; renameAttemptsThusFar < config.MaxRenameAttemptsPerPath; renameAttemptsThusFar++ {
+		candidate, stages, err := sanitizeStages(node, renameAttemptsThusFar, config)
+		if err != nil {
+			return nil, err
+		}
+		if candidate == name || taken == nil || !taken(candidate) {
+			for i := range stages {
+				stages[i].Changes = diffNames(stages[i].Before, stages[i].After)
+			}
+			return append(decoded, stages...), nil
+		}
+	}
+	return nil, fmt.Errorf("failed to rename '%s' (no rename attempts left)", printableName(name))
+}
+
+// diffNames returns the runs of characters in which the names differ, based
+// on their longest common subsequence of runes.
+func diffNames(before, after string) []Change {
+	if before == after {
+		return nil
+	}
+	a, b := []rune(before), []rune(after)
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and
+	// b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var changes []Change
+	var change Change
+	flush := func() {
+		if change.Before != "" || change.After != "" {
+			changes = append(changes, change)
+			change = Change{}
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			flush()
+			i++
+			j++
+		case j == len(b) || i < len(a) && lcs[i+1][j] >= lcs[i][j+1]:
+			change.Before += string(a[i])
+			i++
+		default:
+			change.After += string(b[j])
+			j++
+		}
+	}
+	flush()
+	return changes
+}
+
+// DescribeRune returns the code point, the Unicode name, and the general
+// category of the rune, e.g. "U+00E4 LATIN SMALL LETTER A WITH DIAERESIS (Ll)".
+func DescribeRune(r rune) string {
+	name := runenames.Name(r)
+	if name == "" {
+		name = "<unnamed>"
+	}
+	return fmt.Sprintf("U+%04X %s (%s)", r, name, runeCategory(r))
+}
+
+// runeCategory returns the two-letter Unicode general category of the rune,
+// e.g. "Lu" for uppercase letters, or "Cn" for unassigned code points.
+func runeCategory(r rune) string {
+	categories := make([]string, 0, len(unicode.Categories))
+	for category := range unicode.Categories {
+		// "LC" (cased letters) spans "Lu", "Ll", and "Lt".
+		if len(category) == 2 && category != "LC" {
+			categories = append(categories, category)
+		}
+	}
+	sort.Strings(categories)
+	for _, category := range categories {
+		if unicode.Is(unicode.Categories[category], r) {
+			return category
+		}
+	}
+	return "Cn"
+}
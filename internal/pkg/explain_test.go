@@ -0,0 +1,77 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplainName(t *testing.T) {
+	config := Config{
+		MaxRenameAttemptsPerPath: 10,
+		MaxBasenameLength:        11,
+		Sanitizer:                NewSanitizer(replaceUmlauts, replaceWindowsForbiddenChars),
+	}
+	stages, err := ExplainName("Müll?.mp3", false, config, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []Stage{
+		{Name: "replace-umlauts", Before: "Müll?.mp3", After: "Muell?.mp3",
+			Changes: []Change{{Before: "ü", After: "ue"}}},
+		{Name: "replace-windows-forbidden-chars", Before: "Muell?.mp3", After: "Muell_.mp3",
+			Changes: []Change{{Before: "?", After: "_"}}},
+		{Name: "truncate", Before: "Muell_.mp3", After: "Muell_.mp3"},
+	}, stages)
+
+	taken := func(name string) bool { return name == "Mueller.mp3" }
+	stages, err = ExplainName("Müllers.mp3", false, config, taken)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"replace-umlauts", "replace-windows-forbidden-chars", "truncate", "collision-suffix"}, stageNames(stages))
+	assert.Equal(t, Stage{Name: "truncate", Before: "Muellers.mp3", After: "Mueller.mp3",
+		Changes: []Change{{Before: "s", After: ""}}}, stages[2])
+	assert.Equal(t, Stage{Name: "collision-suffix", Before: "Mueller.mp3", After: "Mueller_1.mp3",
+		Changes: []Change{{Before: "", After: "_1"}}}, stages[3])
+
+	config.MaxRenameAttemptsPerPath = 1
+	_, err = ExplainName("Müllers.mp3", false, config, taken)
+	assert.EqualError(t, err, "failed to rename 'Müllers.mp3' (no rename attempts left)")
+}
+
+func TestExplainNameDecodes(t *testing.T) {
+	decoder, err := NewNameDecoder("auto")
+	assert.NoError(t, err)
+	config := Config{
+		MaxRenameAttemptsPerPath: 1,
+		MaxBasenameLength:        255,
+		Sanitizer:                NewSanitizer(replaceUmlauts),
+		Decoder:                  decoder,
+	}
+	stages, err := ExplainName("R\xe4tsel.mp3", false, config, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"decode-cp1252", "replace-umlauts", "truncate"}, stageNames(stages))
+	assert.Equal(t, "Rätsel.mp3", stages[0].After)
+	assert.Equal(t, "Raetsel.mp3", stages[2].After)
+}
+
+func stageNames(stages []Stage) []string {
+	var names []string
+	for _, stage := range stages {
+		names = append(names, stage.Name)
+	}
+	return names
+}
+
+func TestDiffNames(t *testing.T) {
+	assert.Nil(t, diffNames("same", "same"))
+	assert.Equal(t, []Change{{Before: "ä", After: "ae"}, {Before: "ö", After: "oe"}}, diffNames("Bär Köln", "Baer Koeln"))
+	assert.Equal(t, []Change{{Before: "\u00e9", After: "e\u0301"}}, diffNames("caf\u00e9", "cafe\u0301"))
+	assert.Equal(t, []Change{{Before: "", After: "_1"}}, diffNames("a.txt", "a.txt_1"))
+	assert.Equal(t, []Change{{Before: "  ", After: ""}}, diffNames("a  ", "a"))
+}
+
+func TestDescribeRune(t *testing.T) {
+	assert.Equal(t, "U+00E4 LATIN SMALL LETTER A WITH DIAERESIS (Ll)", DescribeRune('ä'))
+	assert.Equal(t, "U+003F QUESTION MARK (Po)", DescribeRune('?'))
+	assert.Equal(t, "U+0301 COMBINING ACUTE ACCENT (Mn)", DescribeRune('\u0301'))
+	assert.Equal(t, "U+4E2D <CJK Ideograph> (Lo)", DescribeRune('中'))
+	assert.Equal(t, "U+0378 <unnamed> (Cn)", DescribeRune('\u0378'))
+}
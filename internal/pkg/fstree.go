@@ -100,6 +100,17 @@ func (node FsNode) HasSiblingOfName(name string) bool {
 	return false
 }
 
+// hasSiblingOfNameFold is like HasSiblingOfName, but it compares names
+// regardless of case, like case-insensitive file systems do.
+func (node FsNode) hasSiblingOfNameFold(name string) bool {
+	for _, sibling := range node.Siblings() {
+		if strings.EqualFold(sibling.name, name) {
+			return true
+		}
+	}
+	return false
+}
+
 func (node FsNode) IsRoot() bool {
 	return node.parent == nil
 }
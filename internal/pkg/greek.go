@@ -0,0 +1,108 @@
+package internal
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// transliterateGreek transliterates Greek according to ELOT 743 (type 2),
+// which is identical to the transcription of ISO 843.  For example, "Αθήνα"
+// becomes "Athina", and "Ευαγγέλιο" becomes "Evangelio".
+//
+// The rule must run before "decompose-nfd": it needs to see the dialytika of
+// letters like 'ϊ', which prevents them from forming digraphs (think: "Λαϊκός"
+// is "Laikos", not "Lekos"), before the later rules remove it.
+var transliterateGreek Rule = greekRule{}
+
+// greekLetters maps the lowercase Greek letters without diacritics.  Both the
+// medial 'σ' and the final 'ς' become "s".
+var greekLetters = map[rune]string{
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i",
+	'θ': "th", 'ι': "i", 'κ': "k", 'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x",
+	'ο': "o", 'π': "p", 'ρ': "r", 'σ': "s", 'ς': "s", 'τ': "t", 'υ': "y",
+	'φ': "f", 'χ': "ch", 'ψ': "ps", 'ω': "o",
+	// letter variants
+	'ϐ': "v", 'ϑ': "th", 'ϕ': "f", 'ϖ': "p", 'ϰ': "k", 'ϱ': "r", 'ϲ': "s",
+	'ϳ': "j",
+}
+
+// greekDigraphs maps pairs of letters that ELOT 743 transliterates together.
+// The pairs "αυ", "ευ", "ηυ", and "μπ" depend on their context, see
+// greekRule.lookup.
+var greekDigraphs = map[string]string{
+	"ου": "ou",
+	"γγ": "ng",
+	"γξ": "nx",
+	"γχ": "nch",
+}
+
+type greekRule struct{}
+
+func (r greekRule) Name() string { return "transliterate-greek" }
+
+func (r greekRule) Apply(name string) string {
+	if !strings.ContainsFunc(name, func(c rune) bool { return unicode.Is(unicode.Greek, c) }) {
+		return name
+	}
+	// Compose the name, so that a letter and its diacritics are a single rune.
+	runes := []rune(norm.NFC.String(name))
+	var b strings.Builder
+	for i := 0; i < len(runes); {
+		latin, n, ok := r.lookup(runes, i)
+		if !ok {
+			b.WriteRune(runes[i])
+			i++
+			continue
+		}
+		b.WriteString(matchCase(latin, runes, i, n))
+		i += n
+	}
+	return b.String()
+}
+
+// lookup returns the transliteration of the letter (or pair of letters) at
+// runes[i] and the number of runes it covers.
+func (r greekRule) lookup(runes []rune, i int) (string, int, bool) {
+	c, _ := greekBase(runes[i])
+	if _, ok := greekLetters[c]; !ok {
+		return "", 0, false
+	}
+	if i+1 < len(runes) {
+		// A dialytika on the second letter, as in "αϋ", splits the pair.
+		next, dialytika := greekBase(runes[i+1])
+		if !dialytika {
+			pair := string([]rune{c, next})
+			if latin, ok := greekDigraphs[pair]; ok {
+				return latin, 2, true
+			}
+			switch pair {
+			case "αυ", "ευ", "ηυ":
+				// "v" before vowels and voiced consonants, "f" otherwise.
+				after := ' '
+				if i+2 < len(runes) {
+					after, _ = greekBase(runes[i+2])
+				}
+				if strings.ContainsRune("αεηιουωβγδζλμνρ", after) {
+					return greekLetters[c] + "v", 2, true
+				}
+				return greekLetters[c] + "f", 2, true
+			case "μπ":
+				// "b" at the beginning and end of a word, "mp" in between.
+				if i == 0 || !isWordRune(runes[i-1]) || i+2 == len(runes) || !isWordRune(runes[i+2]) {
+					return "b", 2, true
+				}
+				return "mp", 2, true
+			}
+		}
+	}
+	return greekLetters[c], 1, true
+}
+
+// greekBase returns the lowercase base letter of a Greek letter with
+// diacritics, such as 'α' for 'Ά', and whether the letter has a dialytika.
+func greekBase(c rune) (rune, bool) {
+	decomposed := []rune(norm.NFD.String(string(c)))
+	return unicode.ToLower(decomposed[0]), strings.ContainsRune(string(decomposed[1:]), '\u0308')
+}
@@ -0,0 +1,53 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Examples from ELOT 743 and ISO 843.
+func TestTransliterateGreek(t *testing.T) {
+	examples := map[string]string{
+		"Αθήνα":           "Athina",
+		"Θεσσαλονίκη":     "Thessaloniki",
+		"Πειραιάς":        "Peiraias",
+		"Χρήστος":         "Christos",
+		"ΧΡΗΣΤΟΣ":         "CHRISTOS",
+		"Ψυχή":            "Psychi",
+		"Μουσική":         "Mousiki",
+		"ΟΥΡΑΝΟΣ":         "OURANOS",
+		"Άγγελος":         "Angelos",
+		"Σφίγξ":           "Sfinx",
+		"Ελέγχω":          "Elencho",
+		"Γκάνα":           "Gkana",
+		"Ευάγγελος":       "Evangelos",
+		"Αυτοκίνητο":      "Aftokinito",
+		"Νεύρο":           "Nevro",
+		"Ζευς":            "Zefs",
+		"ηύρα":            "ivra",
+		"Μπαμπάς":         "Bampas",
+		"ΜΠΑΜΠΑΣ":         "BAMPAS",
+		"λαμπ":            "lab",
+		"Λαϊκός":          "Laikos",
+		"Πραΰνω":          "Prayno",
+		"Ἀριστοτέλης":     "Aristotelis",
+		"Φωτογραφίες.jpg": "Fotografies.jpg",
+	}
+	for greek, latin := range examples {
+		assert.Equal(t, latin, transliterateGreek.Apply(greek), greek)
+		assert.Equal(t, latin, transliterateGreek.Apply(norm.NFD.String(greek)), "decomposed %s", greek)
+	}
+}
+
+func TestTransliterateGreekKeepsOtherNames(t *testing.T) {
+	decomposed := norm.NFD.String("Café")
+	assert.Equal(t, decomposed, transliterateGreek.Apply(decomposed))
+}
+
+func TestSanitizeTransliteratesGreek(t *testing.T) {
+	assert.Equal(t, "Athina 2004.mp4", Sanitize("Αθήνα 2004.mp4"))
+	assert.Equal(t, "Laikos", Sanitize("Λαϊκός"))
+	assert.True(t, isPrintableASCII(Sanitize("ΑΒΓΔΕΖΗΘΙΚΛΜΝΞΟΠΡΣΤΥΦΧΨΩ αβγδεζηθικλμνξοπρσςτυφχψω άέήίόύώϊϋΐΰ")))
+}
@@ -0,0 +1,113 @@
+package internal
+
+import "strings"
+
+// Hangul syllables are composed algorithmically from an initial consonant, a
+// vowel, and an optional final consonant, see
+// https://www.unicode.org/versions/latest/ch03.pdf (section 3.12).
+const (
+	hangulFirst  = 0xAC00
+	hangulLast   = 0xD7A3
+	hangulVowels = 21
+	hangulFinals = 28
+)
+
+// Revised Romanization of Korean, indexed by jamo number.
+var (
+	hangulInitialRR = []string{
+		"g", "kk", "n", "d", "tt", "r", "m", "b", "pp", "s", "ss", "", "j", "jj", "ch", "k", "t", "p", "h",
+	}
+	hangulVowelRR = []string{
+		"a", "ae", "ya", "yae", "eo", "e", "yeo", "ye", "o", "wa", "wae", "oe", "yo", "u", "wo", "we", "wi",
+		"yu", "eu", "ui", "i",
+	}
+	// hangulFinalRR is the final consonant at the end of a word and before
+	// another consonant.
+	hangulFinalRR = []string{
+		"", "k", "k", "k", "n", "n", "n", "t", "l", "k", "m", "p", "l", "l", "p", "l", "m", "p", "p", "t",
+		"t", "ng", "t", "t", "k", "t", "p", "t",
+	}
+	// hangulLinkedRR is the final consonant before a vowel, where it is
+	// pronounced as the initial consonant of the next syllable.
+	hangulLinkedRR = []string{
+		"", "g", "kk", "ks", "n", "nj", "n", "d", "r", "lg", "lm", "lb", "ls", "lt", "lp", "r", "m", "b",
+		"ps", "s", "ss", "ng", "j", "ch", "k", "t", "p", "",
+	}
+)
+
+// Jamo numbers of the consonants that take part in sound changes.
+const (
+	initialN  = 2
+	initialR  = 5
+	initialM  = 6
+	initialNG = 11 // the silent 'ㅇ' of syllables that start with a vowel
+)
+
+func isHangulSyllable(r rune) bool {
+	return r >= hangulFirst && r <= hangulLast
+}
+
+// romanizeHangul romanizes a run of Hangul syllables according to the Revised
+// Romanization of Korean.  Besides the linking of final consonants to a
+// following vowel, it applies the most common sound changes: 'ㄴ' and 'ㄹ' next
+// to 'ㄹ' become "ll" (as in "Silla"), other consonants turn 'ㄹ' into "n" (as
+// in "Jongno"), and final consonants before 'ㄴ', 'ㄹ', or 'ㅁ' become nasals
+// (as in "hamnida").
+func romanizeHangul(syllables []rune) string {
+	var b strings.Builder
+	for i, s := range syllables {
+		initial, vowel, final := decomposeHangul(s)
+		previous := ""
+		if i > 0 {
+			_, _, previousFinal := decomposeHangul(syllables[i-1])
+			previous = hangulFinalRR[previousFinal]
+		}
+		switch {
+		case initial == initialR && (previous == "l" || previous == "n"), initial == initialN && previous == "l":
+			b.WriteString("l")
+		case initial == initialR && previous != "":
+			b.WriteString("n")
+		default:
+			b.WriteString(hangulInitialRR[initial])
+		}
+		b.WriteString(hangulVowelRR[vowel])
+
+		latin := hangulFinalRR[final]
+		if final == 0 || i+1 == len(syllables) {
+			b.WriteString(latin)
+			continue
+		}
+		next, _, _ := decomposeHangul(syllables[i+1])
+		switch {
+		case next == initialNG:
+			b.WriteString(hangulLinkedRR[final])
+		case latin == "l" && (next == initialR || next == initialN), latin == "n" && next == initialR:
+			b.WriteString("l")
+		case next == initialN || next == initialR || next == initialM:
+			b.WriteString(nasalize(latin))
+		default:
+			b.WriteString(latin)
+		}
+	}
+	return b.String()
+}
+
+// nasalize returns the nasal that a final consonant becomes before a nasal.
+func nasalize(latin string) string {
+	switch latin {
+	case "k":
+		return "ng"
+	case "t":
+		return "n"
+	case "p":
+		return "m"
+	}
+	return latin
+}
+
+// decomposeHangul returns the jamo numbers of the initial consonant, the
+// vowel, and the final consonant (0 if none) of a Hangul syllable.
+func decomposeHangul(s rune) (int, int, int) {
+	index := int(s - hangulFirst)
+	return index / (hangulVowels * hangulFinals), index % (hangulVowels * hangulFinals) / hangulFinals, index % hangulFinals
+}
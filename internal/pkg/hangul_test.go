@@ -0,0 +1,30 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Examples from the Revised Romanization of Korean.
+func TestRomanizeHangul(t *testing.T) {
+	examples := map[string]string{
+		"서울":    "seoul",
+		"부산":    "busan",
+		"한국어":   "hangugeo",
+		"방탄소년단": "bangtansonyeondan",
+		"좋아요":   "joayo",
+		"읽어":    "ilgeo",
+		"신라":    "silla",
+		"설날":    "seollal",
+		"종로":    "jongno",
+		"국물":    "gungmul",
+		"합니다":   "hamnida",
+		"백마":    "baengma",
+		"김치":    "gimchi",
+		"떡볶이":   "tteokbokki",
+	}
+	for hangul, latin := range examples {
+		assert.Equal(t, latin, romanizeHangul([]rune(hangul)), hangul)
+	}
+}
@@ -0,0 +1,178 @@
+package internal
+
+import (
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// hebrewLetters maps the Hebrew consonants according to the simplified
+// romanization of the Academy of the Hebrew Language (2006).  The letters 'ב',
+// 'כ', and 'פ' are mapped without dagesh, and the letters 'ו' and 'י', which
+// are consonants or vowels, are handled by transliterateHebrew.
+var hebrewLetters = map[rune]string{
+	'א': "", 'ב': "v", 'ג': "g", 'ד': "d", 'ה': "h", 'ז': "z", 'ח': "h",
+	'ט': "t", 'כ': "kh", 'ך': "kh", 'ל': "l", 'מ': "m", 'ם': "m", 'נ': "n",
+	'ן': "n", 'ס': "s", 'ע': "", 'פ': "f", 'ף': "f", 'צ': "ts", 'ץ': "ts",
+	'ק': "k", 'ר': "r", 'ש': "sh", 'ת': "t",
+}
+
+// hebrewHard maps the letters whose sound changes with a dagesh.
+var hebrewHard = map[rune]string{'ב': "b", 'כ': "k", 'ך': "k", 'פ': "p", 'ף': "p"}
+
+// hebrewGeresh maps the letters whose sound changes with a geresh, as in
+// "ג׳ירפה" (giraffe).
+var hebrewGeresh = map[rune]string{'ג': "j", 'ז': "zh", 'צ': "ch", 'ץ': "ch"}
+
+// hebrewVowels maps the vowel points (niqqud).
+var hebrewVowels = map[rune]string{
+	'ֱ': "e", 'ֲ': "a", 'ֳ': "o", 'ִ': "i", 'ֵ': "e",
+	'ֶ': "e", 'ַ': "a", 'ָ': "a", 'ֹ': "o", 'ֺ': "o",
+	'ֻ': "u", 'ׇ': "o",
+}
+
+const (
+	hebrewShva       = 'ְ'
+	hebrewDagesh     = 'ּ'
+	hebrewShinDot    = 'ׁ'
+	hebrewSinDot     = 'ׂ'
+	hebrewGereshMark = '׳'
+)
+
+// hebrewMarks holds the marks that follow a Hebrew letter.
+type hebrewMarks struct {
+	vowel   string
+	pointed bool // the letter has a vowel point or a shva
+	dagesh  bool
+	sin     bool
+	geresh  bool
+}
+
+// transliterateHebrew romanizes a run of Hebrew script.  Vowels are only
+// transliterated if they are written with vowel points, which most Hebrew
+// text omits, so that "שָׁלוֹם" becomes "shalom", but "שלום" becomes "shlom".
+func transliterateHebrew(run []rune) string {
+	runes := []rune(norm.NFD.String(string(run)))
+	var b strings.Builder
+	wordStart := true
+	previous := hebrewMarks{}
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		if !isHebrewLetter(c) {
+			switch {
+			case c == '־': // maqaf, the Hebrew hyphen
+				b.WriteByte('-')
+			case c == hebrewGereshMark:
+				b.WriteByte('\'')
+			case c == '״' || c >= '֑' && c <= 'ׇ':
+				// Gershayim, which mark abbreviations, and cantillation
+				// marks are dropped.
+			default:
+				b.WriteRune(c)
+			}
+			wordStart = true
+			previous = hebrewMarks{}
+			continue
+		}
+		m, n := readHebrewMarks(runes[i+1:])
+		j := i + 1 + n
+		wordEnd := j >= len(runes) || !isHebrewLetter(runes[j])
+
+		vowel := m.vowel
+		if m.pointed && vowel == "" && wordStart {
+			vowel = "e" // the shva at the beginning of a word is pronounced
+		}
+		var latin string
+		switch c {
+		case 'ו':
+			switch {
+			case !m.pointed && m.dagesh:
+				latin, vowel = "", "u" // shuruk
+			case vowel == "o" && !wordStart:
+				latin = "" // holam male
+			case j < len(runes) && runes[j] == 'ו':
+				latin = "v"
+				i++
+			case m.pointed, wordStart, previous.pointed:
+				latin = "v"
+			default:
+				latin = "o"
+			}
+		case 'י':
+			switch {
+			case m.pointed, wordStart:
+				latin = "y"
+			case previous.vowel == "i" || previous.vowel == "e":
+				latin = "" // hiriq or tsere male
+			case !previous.pointed && !wordEnd && strings.ContainsRune("ואה", runes[j]):
+				latin = "y"
+			default:
+				latin = "i"
+			}
+		case 'ש':
+			latin = "sh"
+			if m.sin {
+				latin = "s"
+			}
+		case 'ה':
+			// The silent 'ה' at the end of a word marks a vowel, which
+			// is only written if it has no vowel point.
+			switch {
+			case !wordEnd || m.dagesh || m.pointed:
+				latin = "h"
+			case !previous.pointed:
+				latin = "a"
+			}
+		default:
+			latin = hebrewLetters[c]
+			if hard, ok := hebrewHard[c]; ok && (m.dagesh || wordStart) {
+				latin = hard
+			}
+			if geresh, ok := hebrewGeresh[c]; ok && m.geresh {
+				latin = geresh
+			}
+		}
+		b.WriteString(latin)
+		b.WriteString(vowel)
+		i += n
+		wordStart = false
+		previous = m
+	}
+	return b.String()
+}
+
+// readHebrewMarks reads the marks at the start of runes, and returns them
+// with their number.
+func readHebrewMarks(runes []rune) (hebrewMarks, int) {
+	var m hebrewMarks
+	n := 0
+	for ; n < len(runes); n++ {
+		c := runes[n]
+		if vowel, ok := hebrewVowels[c]; ok {
+			m.vowel = vowel
+			m.pointed = true
+			continue
+		}
+		switch c {
+		case hebrewShva:
+			m.pointed = true
+		case hebrewDagesh:
+			m.dagesh = true
+		case hebrewSinDot:
+			m.sin = true
+		case hebrewGereshMark:
+			m.geresh = true
+		case hebrewShinDot, 'ֿ': // the rafe is dropped
+		default:
+			if c < '֑' || c > '֯' { // cantillation marks
+				return m, n
+			}
+		}
+	}
+	return m, n
+}
+
+func isHebrewLetter(c rune) bool {
+	_, ok := hebrewLetters[c]
+	return ok || c == 'ו' || c == 'י'
+}
@@ -0,0 +1,24 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/unicode/norm"
+)
+
+func TestTransliterateHebrew(t *testing.T) {
+	examples := map[string]string{
+		"שָׁלוֹם":       "shalom",
+		"שלום":          "shlom",
+		"תֵּל אָבִיב":   "tel aviv",
+		"יְרוּשָׁלַיִם": "yerushalayim",
+		"תּוֹרָה":       "tora",
+		"ג׳ירפה":        "jirfa",
+		"בית־ספר":       "bit-sfr",
+	}
+	for hebrew, latin := range examples {
+		assert.Equal(t, latin, transliterateHebrew([]rune(hebrew)), hebrew)
+		assert.Equal(t, latin, transliterateHebrew([]rune(norm.NFC.String(hebrew))), "composed %s", hebrew)
+	}
+}
@@ -0,0 +1,129 @@
+package internal
+
+import (
+	"strings"
+	"unicode"
+)
+
+// hiraganaHepburn maps hiragana to modified Hepburn romanization.  Katakana
+// are mapped by their hiragana counterparts (see toHiragana).
+var hiraganaHepburn = map[string]string{
+	"あ": "a", "い": "i", "う": "u", "え": "e", "お": "o",
+	"か": "ka", "き": "ki", "く": "ku", "け": "ke", "こ": "ko",
+	"が": "ga", "ぎ": "gi", "ぐ": "gu", "げ": "ge", "ご": "go",
+	"さ": "sa", "し": "shi", "す": "su", "せ": "se", "そ": "so",
+	"ざ": "za", "じ": "ji", "ず": "zu", "ぜ": "ze", "ぞ": "zo",
+	"た": "ta", "ち": "chi", "つ": "tsu", "て": "te", "と": "to",
+	"だ": "da", "ぢ": "ji", "づ": "zu", "で": "de", "ど": "do",
+	"な": "na", "に": "ni", "ぬ": "nu", "ね": "ne", "の": "no",
+	"は": "ha", "ひ": "hi", "ふ": "fu", "へ": "he", "ほ": "ho",
+	"ば": "ba", "び": "bi", "ぶ": "bu", "べ": "be", "ぼ": "bo",
+	"ぱ": "pa", "ぴ": "pi", "ぷ": "pu", "ぺ": "pe", "ぽ": "po",
+	"ま": "ma", "み": "mi", "む": "mu", "め": "me", "も": "mo",
+	"や": "ya", "ゆ": "yu", "よ": "yo",
+	"ら": "ra", "り": "ri", "る": "ru", "れ": "re", "ろ": "ro",
+	"わ": "wa", "ゐ": "i", "ゑ": "e", "を": "o", "ん": "n", "ゔ": "vu",
+	"ぁ": "a", "ぃ": "i", "ぅ": "u", "ぇ": "e", "ぉ": "o",
+	"ゃ": "ya", "ゅ": "yu", "ょ": "yo", "ゎ": "wa",
+
+	// combinations with small vowels, which are mostly written in katakana
+	"ふぁ": "fa", "ふぃ": "fi", "ふぇ": "fe", "ふぉ": "fo", "ふゅ": "fyu",
+	"てぃ": "ti", "でぃ": "di", "とぅ": "tu", "どぅ": "du", "てゅ": "tyu", "でゅ": "dyu",
+	"うぃ": "wi", "うぇ": "we", "うぉ": "wo", "いぇ": "ye",
+	"ゔぁ": "va", "ゔぃ": "vi", "ゔぇ": "ve", "ゔぉ": "vo",
+	"しぇ": "she", "じぇ": "je", "ちぇ": "che", "つぁ": "tsa",
+}
+
+func init() {
+	// Combinations with small 'ゃ', 'ゅ', and 'ょ' (youon), like "kya".
+	for _, c := range "きぎしじちぢにひびぴみり" {
+		base := strings.TrimSuffix(hiraganaHepburn[string(c)], "i")
+		if base != "sh" && base != "j" && base != "ch" {
+			// "kya", but "sha" rather than "shya"
+			base += "y"
+		}
+		for small, vowel := range map[rune]string{'ゃ': "a", 'ゅ': "u", 'ょ': "o"} {
+			hiraganaHepburn[string([]rune{c, small})] = base + vowel
+		}
+	}
+}
+
+// Particles written in hiragana that are pronounced differently from their
+// spelling when they stand on their own, as in "Kimi no Na wa".
+var particleHepburn = map[string]string{"は": "wa", "へ": "e", "を": "o"}
+
+// isKana reports whether the rune is a kana or the prolonged sound mark 'ー',
+// which is neither hiragana nor katakana in Unicode.
+func isKana(r rune) bool {
+	return unicode.In(r, unicode.Hiragana, unicode.Katakana) && r != '・' || r == 'ー'
+}
+
+// toHiragana converts katakana to hiragana.  Other runes, including the
+// prolonged sound mark 'ー', are kept.
+func toHiragana(r rune) rune {
+	if r >= 'ァ' && r <= 'ヶ' || r == 'ヽ' || r == 'ヾ' {
+		return r - 0x60
+	}
+	return r
+}
+
+// romanizeKana romanizes a run of kana according to the modified Hepburn
+// system.  Long vowels marked with 'ー' get a macron, which later rules remove.
+func romanizeKana(kana []rune) string {
+	if latin, ok := particleHepburn[string(kana)]; ok {
+		return latin
+	}
+	var syllables []string
+	for i := 0; i < len(kana); {
+		c := toHiragana(kana[i])
+		if i+1 < len(kana) {
+			if latin, ok := hiraganaHepburn[string([]rune{c, toHiragana(kana[i+1])})]; ok {
+				syllables = append(syllables, latin)
+				i += 2
+				continue
+			}
+		}
+		switch {
+		case c == 'っ':
+			syllables = append(syllables, "っ")
+		case c == 'ー':
+			syllables = append(syllables, "\u0304") // combining macron
+		case c == 'ゝ' || c == 'ゞ':
+			// iteration marks repeat the previous syllable
+			if len(syllables) > 0 {
+				syllables = append(syllables, syllables[len(syllables)-1])
+			}
+		default:
+			latin, ok := hiraganaHepburn[string(c)]
+			if !ok {
+				latin = string(kana[i])
+			}
+			syllables = append(syllables, latin)
+		}
+		i++
+	}
+
+	var b strings.Builder
+	for i, s := range syllables {
+		next := ""
+		if i+1 < len(syllables) {
+			next = syllables[i+1]
+		}
+		switch {
+		case s == "っ":
+			// The small 'っ' (sokuon) doubles the next consonant, as in
+			// "kitte", or adds a "t" before "ch", as in "matcha".
+			if strings.HasPrefix(next, "ch") {
+				b.WriteString("t")
+			} else if next != "" && !strings.ContainsRune("aeiou", rune(next[0])) {
+				b.WriteByte(next[0])
+			}
+		case s == "n" && next != "" && strings.ContainsRune("aeiouy", rune(next[0])):
+			// "Jun'ichi" rather than "Junichi"
+			b.WriteString("n'")
+		default:
+			b.WriteString(s)
+		}
+	}
+	return b.String()
+}
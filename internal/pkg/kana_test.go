@@ -0,0 +1,34 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Examples from the modified Hepburn system.
+func TestRomanizeKana(t *testing.T) {
+	examples := map[string]string{
+		"ひらがな":   "hiragana",
+		"カタカナ":   "katakana",
+		"しんぶん":   "shinbun",
+		"じゅんいち":  "jun'ichi",
+		"きって":    "kitte",
+		"まっちゃ":   "matcha",
+		"きょうと":   "kyouto",
+		"ちゃ":     "cha",
+		"つなみ":    "tsunami",
+		"ふじ":     "fuji",
+		"ファイル":   "fairu",
+		"パーティー":  "pātī",
+		"コーヒー":   "kōhī",
+		"ヴァイオリン": "vaiorin",
+		"いすゞ":    "isusu",
+		"は":      "wa",
+		"を":      "o",
+	}
+	for kana, latin := range examples {
+		assert.Equal(t, latin, norm.NFC.String(romanizeKana([]rune(kana))), kana)
+	}
+}
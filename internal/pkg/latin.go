@@ -0,0 +1,244 @@
+package internal
+
+// transliterateLatin replaces Latin letters that have no Unicode decomposition,
+// and thus survive the removal of diacritics, with ASCII.  For example, 'æ'
+// becomes "ae", 'ø' becomes "o", and 'þ' becomes "th".
+//
+// The rule must run after "remove-nonspacing-marks": letters with diacritics
+// (like 'ǿ') have been decomposed into their base letters (like 'ø') by then,
+// so the table only needs to cover the base letters.
+var transliterateLatin = NewTransliterateRule("transliterate-latin", latinASCII)
+
+// latinASCII follows the spirit of the CLDR transform "Latin-ASCII", see
+// https://github.com/unicode-org/cldr/blob/main/common/transforms/Latin-ASCII.xml
+// Letters not covered by CLDR, such as the tone letters of Zhuang, are mapped
+// to their closest ASCII look-alikes.
+var latinASCII = map[rune]string{
+	// Latin-1 Supplement
+	'Æ': "AE",
+	'Ð': "D",
+	'Ø': "O",
+	'Þ': "TH",
+	'ß': "ss",
+	'æ': "ae",
+	'ð': "d",
+	'ø': "o",
+	'þ': "th",
+
+	// Latin Extended-A
+	'Đ': "D",
+	'đ': "d",
+	'Ħ': "H",
+	'ħ': "h",
+	'ı': "i",
+	'Ĳ': "IJ",
+	'ĳ': "ij",
+	'ĸ': "q",
+	'Ŀ': "L",
+	'ŀ': "l",
+	'Ł': "L",
+	'ł': "l",
+	'ŉ': "'n",
+	'Ŋ': "N",
+	'ŋ': "n",
+	'Œ': "OE",
+	'œ': "oe",
+	'Ŧ': "T",
+	'ŧ': "t",
+	'ſ': "s",
+
+	// Latin Extended-B
+	'ƀ': "b",
+	'Ɓ': "B",
+	'Ƃ': "B",
+	'ƃ': "b",
+	'Ƅ': "6",
+	'ƅ': "6",
+	'Ɔ': "O",
+	'Ƈ': "C",
+	'ƈ': "c",
+	'Ɖ': "D",
+	'Ɗ': "D",
+	'Ƌ': "D",
+	'ƌ': "d",
+	'ƍ': "d",
+	'Ǝ': "E",
+	'Ə': "E",
+	'Ɛ': "E",
+	'Ƒ': "F",
+	'ƒ': "f",
+	'Ɠ': "G",
+	'Ɣ': "G",
+	'ƕ': "hv",
+	'Ɩ': "I",
+	'Ɨ': "I",
+	'Ƙ': "K",
+	'ƙ': "k",
+	'ƚ': "l",
+	'ƛ': "l",
+	'Ɯ': "M",
+	'Ɲ': "N",
+	'ƞ': "n",
+	'Ɵ': "O",
+	'Ƣ': "OI",
+	'ƣ': "oi",
+	'Ƥ': "P",
+	'ƥ': "p",
+	'Ʀ': "R",
+	'Ƨ': "2",
+	'ƨ': "2",
+	'Ʃ': "SH",
+	'ƪ': "sh",
+	'ƫ': "t",
+	'Ƭ': "T",
+	'ƭ': "t",
+	'Ʈ': "T",
+	'Ʊ': "U",
+	'Ʋ': "V",
+	'Ƴ': "Y",
+	'ƴ': "y",
+	'Ƶ': "Z",
+	'ƶ': "z",
+	'Ʒ': "Z",
+	'Ƹ': "Z",
+	'ƹ': "z",
+	'ƺ': "z",
+	'ƻ': "2",
+	'Ƽ': "5",
+	'ƽ': "5",
+	'ƾ': "ts",
+	'ƿ': "w",
+	'ǀ': "|",
+	'ǁ': "||",
+	'ǂ': "=",
+	'ǃ': "!",
+	'Ǆ': "DZ",
+	'ǅ': "Dz",
+	'ǆ': "dz",
+	'Ǉ': "LJ",
+	'ǈ': "Lj",
+	'ǉ': "lj",
+	'Ǌ': "NJ",
+	'ǋ': "Nj",
+	'ǌ': "nj",
+	'ǝ': "e",
+	'Ǥ': "G",
+	'ǥ': "g",
+	'Ǳ': "DZ",
+	'ǲ': "Dz",
+	'ǳ': "dz",
+	'Ƕ': "Hv",
+	'Ƿ': "W",
+	'Ȝ': "Y",
+	'ȝ': "y",
+	'Ƞ': "N",
+	'ȡ': "d",
+	'Ȣ': "OU",
+	'ȣ': "ou",
+	'Ȥ': "Z",
+	'ȥ': "z",
+	'ȴ': "l",
+	'ȵ': "n",
+	'ȶ': "t",
+	'ȷ': "j",
+	'ȸ': "db",
+	'ȹ': "qp",
+	'Ⱥ': "A",
+	'Ȼ': "C",
+	'ȼ': "c",
+	'Ƚ': "L",
+	'Ⱦ': "T",
+	'ȿ': "s",
+	'ɀ': "z",
+	'Ɂ': "'",
+	'ɂ': "'",
+	'Ƀ': "B",
+	'Ʉ': "U",
+	'Ʌ': "V",
+	'Ɇ': "E",
+	'ɇ': "e",
+	'Ɉ': "J",
+	'ɉ': "j",
+	'Ɋ': "Q",
+	'ɋ': "q",
+	'Ɍ': "R",
+	'ɍ': "r",
+	'Ɏ': "Y",
+	'ɏ': "y",
+
+	// IPA Extensions (only letters that also appear in orthographies)
+	'ɐ': "a",
+	'ɑ': "a",
+	'ɒ': "a",
+	'ɓ': "b",
+	'ɔ': "o",
+	'ɕ': "c",
+	'ɖ': "d",
+	'ɗ': "d",
+	'ə': "e",
+	'ɛ': "e",
+	'ɠ': "g",
+	'ɡ': "g",
+	'ɢ': "G",
+	'ɣ': "g",
+	'ɦ': "h",
+	'ɨ': "i",
+	'ɩ': "i",
+	'ɪ': "I",
+	'ɫ': "l",
+	'ɬ': "l",
+	'ɭ': "l",
+	'ɯ': "m",
+	'ɱ': "m",
+	'ɲ': "n",
+	'ɳ': "n",
+	'ɴ': "N",
+	'ɵ': "o",
+	'ɶ': "OE",
+	'ɹ': "r",
+	'ɼ': "r",
+	'ɽ': "r",
+	'ɾ': "r",
+	'ʀ': "R",
+	'ʂ': "s",
+	'ʃ': "sh",
+	'ʈ': "t",
+	'ʉ': "u",
+	'ʊ': "u",
+	'ʋ': "v",
+	'ʌ': "v",
+	'ʏ': "Y",
+	'ʐ': "z",
+	'ʑ': "z",
+	'ʒ': "z",
+	'ʔ': "'",
+	'ʙ': "B",
+	'ʛ': "G",
+	'ʜ': "H",
+	'ʝ': "j",
+	'ʟ': "L",
+	'ʠ': "q",
+	'ʣ': "dz",
+	'ʥ': "dz",
+	'ʦ': "ts",
+	'ʪ': "ls",
+	'ʫ': "lz",
+
+	// Spacing Modifier Letters, which transliterations of Cyrillic use for
+	// the soft and hard signs.  Double primes become apostrophes, too, because
+	// double quotes are forbidden in SMB names.
+	'ʹ': "'",
+	'ʺ': "'",
+	'ʻ': "'",
+	'ʼ': "'",
+	'ˮ': "'",
+
+	// Latin Extended Additional
+	'ẞ': "SS",
+	'Ỻ': "LL",
+	'ỻ': "ll",
+	'Ỽ': "V",
+	'ỽ': "v",
+	'Ỿ': "Y",
+	'ỿ': "y",
+}
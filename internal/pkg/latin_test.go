@@ -0,0 +1,59 @@
+package internal
+
+import (
+	"fmt"
+	"testing"
+	"unicode"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func isPrintableASCII(s string) bool {
+	for _, r := range s {
+		if r < ' ' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func TestTransliterateLatinCoversLatinBlocks(t *testing.T) {
+	blocks := []struct {
+		name  string
+		first rune
+		last  rune
+	}{
+		{"Latin-1 Supplement", 0x00C0, 0x00FF},
+		{"Latin Extended-A", 0x0100, 0x017F},
+		{"Latin Extended-B", 0x0180, 0x024F},
+	}
+	for _, block := range blocks {
+		for r := block.first; r <= block.last; r++ {
+			if !unicode.IsLetter(r) {
+				continue // e.g., '×' and '÷' in the Latin-1 Supplement
+			}
+			sanitized := Sanitize(string(r))
+			assert.True(t, isPrintableASCII(sanitized),
+				fmt.Sprintf("%s: U+%04X '%c' was sanitized to '%s'", block.name, r, r, sanitized))
+			assert.NotEmpty(t, sanitized, fmt.Sprintf("U+%04X", r))
+		}
+	}
+}
+
+func TestTransliterateLatin(t *testing.T) {
+	assert.Equal(t, "Aebleskiver", Sanitize("Æbleskiver"))
+	assert.Equal(t, "AERO", Sanitize("ÆRØ"))
+	assert.Equal(t, "Ostergaard", Sanitize("Østergaard"))
+	assert.Equal(t, "Thorsmoerk", Sanitize("Þórsmörk"))
+	assert.Equal(t, "THOR", Sanitize("ÞOR"))
+	assert.Equal(t, "Gudrun", Sanitize("Guðrún"))
+	assert.Equal(t, "Oeuvre", Sanitize("Œuvre"))
+	assert.Equal(t, "Diyarbakir", Sanitize("Diyarbakır"))
+	assert.Equal(t, "Malta hobz", Sanitize("Malta ħobż"))
+	assert.Equal(t, "Parallel", Sanitize("Paraŀlel"))
+	assert.Equal(t, "ijs", Sanitize("ĳs"))
+	assert.Equal(t, "Ljubljana", Sanitize("ǈubljana"))
+	assert.Equal(t, "SS", Sanitize("ẞ"))
+	assert.Equal(t, "o", Sanitize("ǿ"), "decomposable letters with non-ASCII base letters")
+	assert.Equal(t, "AE", Sanitize("Ǣ"), "decomposable letters with non-ASCII base letters")
+}
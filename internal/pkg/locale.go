@@ -0,0 +1,189 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Locale determines how the rule "replace-umlauts" expands umlauts and similar
+// letters, which languages write differently in ASCII: German writes 'ä' as
+// "ae", whereas Swedish and Finnish simply drop the dots, so that "Mälaren"
+// becomes "Malaren" rather than "Maelaren".  The locale also determines how the
+// rule "replace-symbols" writes some symbols, like '€' (see NewSymbolRule).
+type Locale struct {
+	Name        string
+	Description string
+	letters     map[rune]string
+	// symbols overrides the default texts of symbols.
+	symbols map[rune]string
+}
+
+// Locales lists the supported locales.  Letters that a locale does not map
+// are left to the later rules, which remove their diacritics.
+var Locales = []Locale{
+	{
+		Name:        "de",
+		Description: "German, which writes umlauts as 'ae', 'oe', and 'ue' (default)",
+		letters: map[rune]string{
+			'ß': "ss", 'ẞ': "SS",
+			'Ä': "Ae", 'Ö': "Oe", 'Ü': "Ue", 'ä': "ae", 'ö': "oe", 'ü': "ue",
+		},
+		symbols: map[rune]string{'€': "Euro"},
+	},
+	{
+		Name:        "da",
+		Description: "Danish, which writes 'æ', 'ø', and 'å' as 'ae', 'oe', and 'aa'",
+		letters: map[rune]string{
+			'Æ': "Ae", 'Ø': "Oe", 'Å': "Aa", 'æ': "ae", 'ø': "oe", 'å': "aa",
+			'Ä': "Ae", 'Ö': "Oe", 'Ü': "Ue", 'ä': "ae", 'ö': "oe", 'ü': "ue",
+			'ß': "ss", 'ẞ': "SS",
+		},
+	},
+	{
+		Name:        "no",
+		Description: "Norwegian, which writes 'æ', 'ø', and 'å' as 'ae', 'oe', and 'aa'",
+		letters: map[rune]string{
+			'Æ': "Ae", 'Ø': "Oe", 'Å': "Aa", 'æ': "ae", 'ø': "oe", 'å': "aa",
+			'Ä': "Ae", 'Ö': "Oe", 'Ü': "Ue", 'ä': "ae", 'ö': "oe", 'ü': "ue",
+			'ß': "ss", 'ẞ': "SS",
+		},
+	},
+	{
+		Name:        "sv",
+		Description: "Swedish, which drops the dots and rings of 'ä', 'ö', and 'å'",
+		letters: map[rune]string{
+			'Ä': "A", 'Ö': "O", 'Å': "A", 'Ü': "U", 'ä': "a", 'ö': "o", 'å': "a", 'ü': "u",
+			'ß': "ss", 'ẞ': "SS",
+		},
+	},
+	{
+		Name:        "fi",
+		Description: "Finnish, which drops the dots and rings of 'ä', 'ö', and 'å'",
+		letters: map[rune]string{
+			'Ä': "A", 'Ö': "O", 'Å': "A", 'Ü': "U", 'ä': "a", 'ö': "o", 'å': "a", 'ü': "u",
+			'ß': "ss", 'ẞ': "SS",
+		},
+	},
+	{
+		Name:        "tr",
+		Description: "Turkish, which drops the dots of 'ö', 'ü', and the dotted 'İ', and maps the dotless 'ı' to 'i'",
+		letters: map[rune]string{
+			'Ö': "O", 'Ü': "U", 'ö': "o", 'ü': "u", 'İ': "I", 'ı': "i",
+			'Ç': "C", 'Ş': "S", 'Ğ': "G", 'ç': "c", 'ş': "s", 'ğ': "g",
+			'ß': "ss", 'ẞ': "SS",
+		},
+		symbols: map[rune]string{'₺': "TL"},
+	},
+}
+
+// LocaleNames returns the names of the supported locales.
+func LocaleNames() []string {
+	var names []string
+	for _, l := range Locales {
+		names = append(names, l.Name)
+	}
+	return names
+}
+
+// LookupLocale returns the locale of the given name (see Locales).
+func LookupLocale(name string) (Locale, error) {
+	for _, l := range Locales {
+		if l.Name == name {
+			return l, nil
+		}
+	}
+	return Locale{}, fmt.Errorf("unknown locale '%s' (must be one of: %s)", name, strings.Join(LocaleNames(), ", "))
+}
+
+// NewLocaleRule returns the rule "replace-umlauts" for the given locale (see
+// Locales).
+func NewLocaleRule(locale string) (Rule, error) {
+	l, err := LookupLocale(locale)
+	if err != nil {
+		return nil, err
+	}
+	return localeRule{letters: l.letters}, nil
+}
+
+var replaceUmlauts, _ = NewLocaleRule("de")
+
+type localeRule struct {
+	letters map[rune]string
+}
+
+func (r localeRule) Name() string { return "replace-umlauts" }
+
+func (r localeRule) Apply(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i := 0; i < len(runes); {
+		latin, n, ok := r.lookup(runes, i)
+		if !ok {
+			b.WriteRune(runes[i])
+			i++
+			continue
+		}
+		// Expansions are title-cased unless they are part of an uppercase
+		// word, so that "Ärger" becomes "Aerger", and "ÄRGER" and "ÄÖÜ"
+		// become "AERGER" and "AEOEUE".
+		if unicode.IsUpper(runes[i]) && isUpperWord(runes, i) {
+			latin = strings.ToUpper(latin)
+		}
+		b.WriteString(latin)
+		i += n
+	}
+	return b.String()
+}
+
+// isUpperWord reports whether the word at runes[i] has at least two letters,
+// all of which are uppercase.  Words are runs of letters and marks.
+func isUpperWord(runes []rune, i int) bool {
+	inWord := func(c rune) bool { return unicode.IsLetter(c) || unicode.IsMark(c) }
+	start, end := i, i
+	for start > 0 && inWord(runes[start-1]) {
+		start--
+	}
+	for end < len(runes) && inWord(runes[end]) {
+		end++
+	}
+	letters := 0
+	for _, c := range runes[start:end] {
+		if unicode.IsLower(c) {
+			return false
+		}
+		if unicode.IsLetter(c) {
+			letters++
+		}
+	}
+	return letters >= 2
+}
+
+// lookup returns the replacement of the letter at runes[i], and the number of
+// runes of the letter.
+//
+// The letter may be decomposed into a base letter and a combining mark, such
+// as U+0308 Combining Diaeresis (https://en.wikipedia.org/wiki/Diaeresis_(diacritic)).
+// This character is a Non-spacing Mark and inherits its script property from
+// the preceding character.  The character is also known as 'double dot above',
+// 'umlaut', 'Greek dialytika', and 'double derivative'.
+//
+// Note how an 'Ä' can actually be two chars: an 'A' followed by U+0308, i.e.,
+// the two dots to be added on top of the 'A'. Try it yourself with
+// "Lovecraft Über" in sanitize_test.go: put your cursor to the left of the 'Ü',
+// then use the arrow keys on your keyboard to move the cursor to the right.
+// You will notice that you need two key presses to get across 'Ü'.
+func (r localeRule) lookup(runes []rune, i int) (string, int, bool) {
+	if i+1 < len(runes) && unicode.Is(unicode.Mn, runes[i+1]) {
+		composed := []rune(norm.NFC.String(string(runes[i : i+2])))
+		if len(composed) == 1 {
+			if latin, ok := r.letters[composed[0]]; ok {
+				return latin, 2, true
+			}
+		}
+	}
+	latin, ok := r.letters[runes[i]]
+	return latin, 1, ok
+}
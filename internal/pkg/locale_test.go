@@ -0,0 +1,49 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/unicode/norm"
+)
+
+func TestLocaleRule(t *testing.T) {
+	examples := []struct{ locale, name, expected string }{
+		{"de", "Ähnlich über Größe", "Aehnlich ueber Groesse"},
+		{"de", "ÄRGER", "AERGER"},
+		{"de", "ÄÖÜ", "AEOEUE"},
+		{"de", "MÜLLER", "MUELLER"},
+		{"de", "BÄR.txt Ü", "BAER.txt Ue"},
+		{"de", "ÜBer", "UeBer"},
+		{"de", "Ä.txt", "Ae.txt"},
+		{"de", "Åre", "Åre"},
+		{"sv", "Mälaren", "Malaren"},
+		{"sv", "Åsa Öberg", "Asa Oberg"},
+		{"fi", "Hämeenlinna", "Hameenlinna"},
+		{"da", "Århus Søren Ærø", "Aarhus Soeren Aeroe"},
+		{"da", "ÆRØ", "AEROE"},
+		{"no", "Tromsø", "Tromsoe"},
+		{"tr", "İstanbul Kadıköy Üsküdar", "Istanbul Kadikoy Uskudar"},
+		{"tr", "Çeşme Ağrı", "Cesme Agri"},
+	}
+	for _, e := range examples {
+		rule, err := NewLocaleRule(e.locale)
+		require.NoError(t, err)
+		assert.Equal(t, e.expected, rule.Apply(e.name), "%s: %s", e.locale, e.name)
+		assert.Equal(t, e.expected, norm.NFC.String(rule.Apply(norm.NFD.String(e.name))),
+			"%s: decomposed %s", e.locale, e.name)
+	}
+}
+
+func TestDefaultLocaleIsGerman(t *testing.T) {
+	german, err := NewLocaleRule("de")
+	require.NoError(t, err)
+	assert.Equal(t, "replace-umlauts", replaceUmlauts.Name())
+	assert.Equal(t, german.Apply("Ähnlich"), replaceUmlauts.Apply("Ähnlich"))
+}
+
+func TestNewLocaleRuleErrors(t *testing.T) {
+	_, err := NewLocaleRule("xx")
+	assert.EqualError(t, err, "unknown locale 'xx' (must be one of: de, da, no, sv, fi, tr)")
+}
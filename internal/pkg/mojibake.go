@@ -0,0 +1,156 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// DefaultMojibakeThreshold is the confidence that the rule "repair-mojibake"
+// requires by default (see NewMojibakeRule).
+const DefaultMojibakeThreshold = 0.8
+
+// maxMojibakePasses limits how often a name may have been double-encoded.
+const maxMojibakePasses = 4
+
+// cp1252 maps the characters of Windows-1252 that differ from Latin-1 to
+// their bytes.
+var cp1252 = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'ˆ': 0x88, '‰': 0x89, 'Š': 0x8A, '‹': 0x8B, 'Œ': 0x8C, 'Ž': 0x8E,
+	'‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95,
+	'–': 0x96, '—': 0x97, '˜': 0x98, '™': 0x99, 'š': 0x9A, '›': 0x9B, 'œ': 0x9C,
+	'ž': 0x9E, 'Ÿ': 0x9F,
+}
+
+// NewMojibakeRule returns the rule "repair-mojibake", which repairs names
+// whose UTF-8 was decoded as Latin-1 or Windows-1252 (CP1252), and encoded
+// as UTF-8 again, as in "RÃ¤tsel.mp3" for "Rätsel.mp3".  Old FTP clients and
+// ZIP tools are known for this.  Names that went through this more than once,
+// like "RÃƒÂ¤tsel.mp3", are repaired in several passes.
+//
+// A pass is only made if the whole name decodes to valid UTF-8, and if the
+// confidence of the repair is at least the threshold, between 0 and 1 (see
+// mojibakeConfidence).
+func NewMojibakeRule(threshold float64) (Rule, error) {
+	if threshold < 0 || threshold > 1 {
+		return nil, fmt.Errorf("mojibake threshold must be between 0 and 1, you provided %g", threshold)
+	}
+	return mojibakeRule{threshold: threshold}, nil
+}
+
+var repairMojibake, _ = NewMojibakeRule(DefaultMojibakeThreshold)
+
+type mojibakeRule struct {
+	threshold float64
+}
+
+func (r mojibakeRule) Name() string { return "repair-mojibake" }
+
+func (r mojibakeRule) Apply(name string) string {
+	passes := r.repair(name)
+	if len(passes) == 0 {
+		return name
+	}
+	return passes[len(passes)-1].repaired
+}
+
+func (r mojibakeRule) Explain(before, after string) string {
+	passes := r.repair(before)
+	steps := []string{fmt.Sprintf("%q", before)}
+	for _, p := range passes {
+		steps = append(steps, fmt.Sprintf("%q (UTF-8 decoded as %s, confidence %.2f)", p.repaired, p.encoding, p.confidence))
+	}
+	return "repaired mojibake: " + strings.Join(steps, " => ")
+}
+
+// mojibakePass is a single repair of a name.
+type mojibakePass struct {
+	repaired   string
+	encoding   string
+	confidence float64
+}
+
+// repair returns the passes that repair the name, if any.
+func (r mojibakeRule) repair(name string) []mojibakePass {
+	var passes []mojibakePass
+	for len(passes) < maxMojibakePasses {
+		repaired, encoding, ok := decodeMojibake(name)
+		if !ok {
+			break
+		}
+		confidence := mojibakeConfidence(repaired)
+		if confidence < r.threshold {
+			break
+		}
+		passes = append(passes, mojibakePass{repaired: repaired, encoding: encoding, confidence: confidence})
+		name = repaired
+	}
+	return passes
+}
+
+// decodeMojibake encodes the name as Latin-1 or CP1252, and decodes the
+// result as UTF-8.  It reports false if the name contains characters of
+// neither encoding, or if the result is not valid UTF-8 other than ASCII.
+func decodeMojibake(name string) (string, string, bool) {
+	var b []byte
+	latin1, windows := false, false
+	for _, c := range norm.NFC.String(name) {
+		if c, ok := cp1252[c]; ok {
+			b = append(b, c)
+			windows = true
+			continue
+		}
+		if c >= 0x100 {
+			return "", "", false
+		}
+		if c >= 0x80 && c < 0xA0 {
+			// C1 control characters, which Latin-1 decoders produce
+			// where CP1252 has letters and punctuation
+			latin1 = true
+		}
+		b = append(b, byte(c))
+	}
+	repaired := string(b)
+	if !utf8.Valid(b) || repaired == name || len(repaired) == utf8.RuneCountInString(repaired) {
+		return "", "", false
+	}
+	encoding := "Latin-1 or CP1252"
+	switch {
+	case latin1 && !windows:
+		encoding = "Latin-1"
+	case windows && !latin1:
+		encoding = "CP1252"
+	case windows && latin1:
+		encoding = "CP1252 and Latin-1"
+	}
+	return repaired, encoding, true
+}
+
+// mojibakeConfidence rates how likely the repaired name is what the name was
+// meant to be, from 0 to 1, by the non-ASCII characters of the repaired name:
+// letters, marks, and digits are likely, punctuation and symbols like '€' are
+// slightly less likely, spaces like U+00A0 are unlikely, and control, format,
+// private use, and unassigned characters are implausible.
+func mojibakeConfidence(repaired string) float64 {
+	var sum float64
+	n := 0
+	for _, c := range repaired {
+		if c < utf8.RuneSelf {
+			continue
+		}
+		n++
+		switch {
+		case unicode.In(c, unicode.L, unicode.M, unicode.N):
+			sum += 1
+		case unicode.In(c, unicode.P, unicode.S):
+			sum += 0.9
+		case unicode.Is(unicode.Z, c):
+			sum += 0.5
+		}
+	}
+	return sum / float64(n)
+}
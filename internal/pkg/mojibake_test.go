@@ -0,0 +1,64 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mojibake decodes the UTF-8 of the name as CP1252 (or as Latin-1 for the
+// bytes that CP1252 does not define).
+func mojibake(name string) string {
+	bytes := map[byte]rune{}
+	for c, b := range cp1252 {
+		bytes[b] = c
+	}
+	var runes []rune
+	for _, b := range []byte(name) {
+		if c, ok := bytes[b]; ok {
+			runes = append(runes, c)
+		} else {
+			runes = append(runes, rune(b))
+		}
+	}
+	return string(runes)
+}
+
+func TestMojibakeRule(t *testing.T) {
+	assert.Equal(t, "RÃ¤tsel.mp3", mojibake("Rätsel.mp3"))
+	assert.Equal(t, "Rätsel.mp3", repairMojibake.Apply("RÃ¤tsel.mp3"))
+	assert.Equal(t, "Rätsel.mp3", repairMojibake.Apply(mojibake(mojibake("Rätsel.mp3"))), "repair in several passes")
+	assert.Equal(t, "Rätsel.mp3", repairMojibake.Apply(mojibake(mojibake(mojibake("Rätsel.mp3")))))
+	assert.Equal(t, "Rechnung 49€ – Müller.pdf", repairMojibake.Apply(mojibake("Rechnung 49€ – Müller.pdf")))
+	assert.Equal(t, "Москва.jpg", repairMojibake.Apply(mojibake("Москва.jpg")))
+	assert.Equal(t, "東京.jpg", repairMojibake.Apply(mojibake("東京.jpg")))
+	assert.Equal(t, "Ärger.txt", repairMojibake.Apply("Ã\u0084rger.txt"), "decoded as Latin-1")
+
+	// names that are not mojibake
+	for _, name := range []string{"Rätsel.mp3", "Ärger – Ø.txt", "Café.txt", "plain.txt", "Москва.jpg", ""} {
+		assert.Equal(t, name, repairMojibake.Apply(name))
+	}
+	assert.Equal(t, "Â ", repairMojibake.Apply("Â "), "a no-break space is below the threshold")
+}
+
+func TestMojibakeRuleThreshold(t *testing.T) {
+	_, err := NewMojibakeRule(1.5)
+	assert.ErrorContains(t, err, "mojibake threshold must be between 0 and 1")
+	strict, err := NewMojibakeRule(1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Rätsel", strict.Apply("RÃ¤tsel"))
+	assert.Equal(t, mojibake("49€"), strict.Apply(mojibake("49€")), "symbols are less likely than letters")
+	assert.Equal(t, "49€", repairMojibake.Apply(mojibake("49€")))
+}
+
+func TestMojibakeRuleExplains(t *testing.T) {
+	before := mojibake(mojibake("Rätsel"))
+	assert.Equal(t,
+		`repaired mojibake: "RÃƒÂ¤tsel" => "RÃ¤tsel" (UTF-8 decoded as CP1252, confidence 0.95) => "Rätsel" (UTF-8 decoded as Latin-1 or CP1252, confidence 1.00)`,
+		repairMojibake.(Explainer).Explain(before, repairMojibake.Apply(before)))
+}
+
+func TestSanitizeRepairsMojibake(t *testing.T) {
+	assert.Equal(t, "Raetsel.mp3", Sanitize("RÃ¤tsel.mp3"))
+	assert.Equal(t, "Uebermut 49Euro.pdf", Sanitize(mojibake(mojibake("Übermut 49€.pdf"))))
+}
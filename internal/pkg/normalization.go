@@ -0,0 +1,36 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// NormalizationForms lists the Unicode normalization forms in which names can
+// be written (see NewNormalizationRule): "nfc", which Windows and Linux
+// clients usually write, e.g. 'é' as U+00E9, and "nfd", which macOS clients
+// usually write, e.g. 'é' as 'e' followed by U+0301.
+var NormalizationForms = []string{"nfc", "nfd"}
+
+// NewNormalizationRule returns the rule "normalize-nfc" or "normalize-nfd",
+// which writes names in the given normalization form (see
+// NormalizationForms).  Names that look the same, but are in different
+// forms, are different names to most file systems.  The rule also writes the
+// characters of an allowlist in the given form, because it keeps them as they
+// are (see Sanitizer.SetAllowlist).
+func NewNormalizationRule(form string) (Rule, error) {
+	switch form {
+	case "nfc":
+		return normalizationRule{normalizeRule{name: "normalize-nfc", form: norm.NFC}}, nil
+	case "nfd":
+		return normalizationRule{normalizeRule{name: "normalize-nfd", form: norm.NFD}}, nil
+	}
+	return nil, fmt.Errorf("unknown normalization form '%s' (must be one of: %s)", form, strings.Join(NormalizationForms, ", "))
+}
+
+// normalizationRule is a normalizeRule that the allowlist of a sanitizer does
+// not apply to (see Sanitizer.apply).
+type normalizationRule struct {
+	normalizeRule
+}
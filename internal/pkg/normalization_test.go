@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizationRule(t *testing.T) {
+	nfc, err := NewNormalizationRule("nfc")
+	assert.NoError(t, err)
+	assert.Equal(t, "normalize-nfc", nfc.Name())
+	assert.Equal(t, "caf\u00e9.txt", nfc.Apply("cafe\u0301.txt"))
+
+	nfd, err := NewNormalizationRule("nfd")
+	assert.NoError(t, err)
+	assert.Equal(t, "normalize-nfd", nfd.Name())
+	assert.Equal(t, "cafe\u0301.txt", nfd.Apply("caf\u00e9.txt"))
+
+	_, err = NewNormalizationRule("nfkc")
+	assert.EqualError(t, err, "unknown normalization form 'nfkc' (must be one of: nfc, nfd)")
+}
+
+func TestApplyNormalization(t *testing.T) {
+	allowlist, err := NewAllowlist("é", nil, nil)
+	assert.NoError(t, err)
+	config := Config{Sanitizer: NewSanitizer(DefaultRules()...)}
+	config.Sanitizer.SetAllowlist(allowlist)
+	assert.NoError(t, config.ApplyNormalization("nfd", false))
+	rules := config.Sanitizer.Rules()
+	assert.Equal(t, "normalize-nfd", rules[len(rules)-1].Name())
+	assert.Equal(t, "Raetsel cafe\u0301.txt", config.Sanitizer.Sanitize("R\u00e4tsel caf\u00e9.txt"), "kept characters are normalized, too")
+	assert.Equal(t, "Raetsel cafe\u0301.txt", config.Sanitizer.Sanitize("Ra\u0308tsel cafe\u0301.txt"))
+
+	config = Config{}
+	assert.NoError(t, config.ApplyNormalization("nfc", true))
+	assert.Len(t, config.Sanitizer.Rules(), 1)
+	assert.Equal(t, "R\u00e4tsel?.txt", config.Sanitizer.Sanitize("Ra\u0308tsel?.txt"))
+
+	assert.Error(t, config.ApplyNormalization("nfkd", false))
+}
+
+func TestRenameNormalizesMixedForms(t *testing.T) {
+	config := Config{
+		MaxRenameAttemptsPerPath: 10,
+		MaxBasenameLength:        255,
+		SilentMode:               true,
+	}
+	assert.NoError(t, config.ApplyNormalization("nfc", true))
+	root := FsNode{name: "share", originalPath: "share", isDir: true}
+	root.AddNestedChild("share/caf\u00e9.txt", false)
+	root.AddNestedChild("share/cafe\u0301.txt", false)
+	assert.NoError(t, Rename(false, &root, config))
+	expected := []string{
+		"share",
+		"share/caf\u00e9.txt",
+		"share/caf\u00e9_1.txt",
+	}
+	assert.Equal(t, expected, root.Paths())
+}
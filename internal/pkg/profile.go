@@ -0,0 +1,252 @@
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Profile bundles the sanitize rules and name length limits of a target
+// system, such as the SMB shares of a Synology NAS or an exFAT-formatted USB
+// drive.
+type Profile struct {
+	Name        string
+	Description string
+	Rules       []Rule
+	// MaxBasenameLength is the max length of a basename in bytes, or 0 if the
+	// target system does not limit the length in bytes.
+	MaxBasenameLength int
+	// MaxBasenameChars is the max length of a basename in characters as
+	// Windows counts them, i.e., in UTF-16 code units, or 0 if the target
+	// system does not limit the length in characters.
+	MaxBasenameChars int
+	// Charset reports whether the target system allows the rune in names, or
+	// is nil if it allows all runes.  The profile's rules are expected to
+	// replace other runes, but sauber only enforces the charset in strict
+	// ASCII mode (see Config.ApplyStrictASCII).
+	Charset func(rune) bool
+}
+
+// Profiles lists the built-in profiles.
+var Profiles = []Profile{
+	{
+		Name:              "smb",
+		Description:       "SMB shares of a Synology NAS (ext4, btrfs)",
+		Rules:             DefaultRules(),
+		MaxBasenameLength: 255,
+		Charset:           windowsRune,
+	},
+	{
+		Name:              "synology-encrypted",
+		Description:       "encrypted shared folders of a Synology NAS",
+		Rules:             DefaultRules(),
+		MaxBasenameLength: 143,
+		Charset:           windowsRune,
+	},
+	{
+		Name:        "windows",
+		Description: "Windows (NTFS, SMB shares of Windows hosts)",
+		Rules: []Rule{
+			replaceControlChars,
+			replaceWindowsForbiddenChars,
+			NewTrimRule(""),
+			rewriteWindowsReservedNames,
+		},
+		MaxBasenameChars: 255,
+		Charset:          windowsRune,
+	},
+	{
+		Name:        "macos",
+		Description: "macOS (APFS, HFS+)",
+		Rules: []Rule{
+			NewForbiddenCharsRule("replace-macos-forbidden-chars", `:/`, '_'),
+		},
+		MaxBasenameChars: 255,
+		Charset:          macosRune,
+	},
+	{
+		Name:        "fat32",
+		Description: "FAT32 (VFAT long file names), e.g., USB drives and SD cards",
+		Rules: []Rule{
+			replaceControlChars,
+			NewForbiddenCharsRule("replace-fat-forbidden-chars", `"*/:<>?\|`, '_'),
+			NewTrimRule(""),
+			rewriteWindowsReservedNames,
+		},
+		MaxBasenameChars: 255,
+		Charset:          windowsRune,
+	},
+	{
+		Name:        "exfat",
+		Description: "exFAT, e.g., USB drives and SD cards",
+		Rules: []Rule{
+			replaceControlChars,
+			NewForbiddenCharsRule("replace-fat-forbidden-chars", `"*/:<>?\|`, '_'),
+			NewTrimRule(""),
+			rewriteWindowsReservedNames,
+		},
+		MaxBasenameChars: 255,
+		Charset:          windowsRune,
+	},
+	{
+		Name:        "posix",
+		Description: "POSIX portable filename character set (A-Z a-z 0-9 . _ -)",
+		Rules: append(DefaultRules(),
+			NewMapRule("replace-non-portable-chars", portableRune),
+			NewRegexRule("replace-leading-hyphen", regexp.MustCompile(`^-`), "_"),
+		),
+		MaxBasenameLength: 255,
+		Charset:           isPortable,
+	},
+}
+
+// replaceControlChars replaces control characters like the bell (U+0007).
+var replaceControlChars = NewCategoryRule("replace-control-chars", unicode.Cc, "-")
+
+func portableRune(r rune) rune {
+	if isPortable(r) {
+		return r
+	}
+	return '_'
+}
+
+// isPortable reports whether the rune is in the POSIX portable filename
+// character set.
+func isPortable(r rune) bool {
+	switch {
+	case r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+		return true
+	case r == '.' || r == '_' || r == '-':
+		return true
+	}
+	return false
+}
+
+// windowsRune reports whether Windows, and thus SMB clients and FAT file
+// systems, allow the rune in names.
+func windowsRune(r rune) bool {
+	return !unicode.Is(unicode.Cc, r) && !strings.ContainsRune(`<>:"/\|?*`, r)
+}
+
+// macosRune reports whether macOS allows the rune in names.
+func macosRune(r rune) bool {
+	return r != ':' && r != '/'
+}
+
+// NewForbiddenCharsRule returns a rule that replaces each of the forbidden
+// characters with the replacement.
+func NewForbiddenCharsRule(name string, forbidden string, replacement rune) Rule {
+	return NewMapRule(name, func(r rune) rune {
+		if strings.ContainsRune(forbidden, r) {
+			return replacement
+		}
+		return r
+	})
+}
+
+// LookupProfile returns the profile of the given name.  Several profiles can
+// be stacked by joining their names with '+', e.g. "smb+fat32" (see
+// StackProfiles).
+func LookupProfile(name string) (Profile, error) {
+	var profiles []Profile
+	for _, n := range strings.Split(name, "+") {
+		found := false
+		for _, p := range Profiles {
+			if p.Name == n {
+				profiles = append(profiles, p)
+				found = true
+			}
+		}
+		if !found {
+			return Profile{}, fmt.Errorf("unknown profile '%s' (must be one of: %s)", n, ProfileNames())
+		}
+	}
+	return StackProfiles(profiles...), nil
+}
+
+// ProfileNames returns the comma-separated names of the built-in profiles.
+func ProfileNames() string {
+	var names []string
+	for _, p := range Profiles {
+		names = append(names, p.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// StackProfiles combines several profiles into one, so that sanitized names
+// satisfy all of them.  The rules of the stacked profile are the rules of the
+// given profiles, with duplicate rules (by name) removed, in the order of
+// DefaultRules, so that the rules which finish names, like
+// "trim-dots-and-spaces", run after the rules of all profiles.  Rules that
+// are not built in run right after the built-in rule that precedes them in
+// their profile.  Its length limits are the strictest limits of the given
+// profiles, and its charset allows the runes that all of them allow.
+func StackProfiles(profiles ...Profile) Profile {
+	var stacked Profile
+	var names, descriptions []string
+	order := map[string]int{}
+	for i, rule := range DefaultRules() {
+		order[rule.Name()] = i
+	}
+	type rankedRule struct {
+		rule Rule
+		// rank is the index of the rule in DefaultRules or, for rules that
+		// are not built in, the index of the built-in rule that precedes it.
+		rank    int
+		builtin bool
+	}
+	var ranked []rankedRule
+	seen := map[string]bool{}
+	for _, p := range profiles {
+		names = append(names, p.Name)
+		descriptions = append(descriptions, p.Description)
+		rank := -1
+		for _, rule := range p.Rules {
+			i, builtin := order[rule.Name()]
+			if builtin {
+				rank = i
+			}
+			if !seen[rule.Name()] {
+				seen[rule.Name()] = true
+				ranked = append(ranked, rankedRule{rule: rule, rank: rank, builtin: builtin})
+			}
+		}
+		stacked.Charset = bothCharsets(stacked.Charset, p.Charset)
+		stacked.MaxBasenameLength = minLimit(stacked.MaxBasenameLength, p.MaxBasenameLength)
+		stacked.MaxBasenameChars = minLimit(stacked.MaxBasenameChars, p.MaxBasenameChars)
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].rank != ranked[j].rank {
+			return ranked[i].rank < ranked[j].rank
+		}
+		return ranked[i].builtin && !ranked[j].builtin
+	})
+	for _, r := range ranked {
+		stacked.Rules = append(stacked.Rules, r.rule)
+	}
+	stacked.Name = strings.Join(names, "+")
+	stacked.Description = strings.Join(descriptions, " + ")
+	return stacked
+}
+
+// bothCharsets returns the charset of the runes that both charsets allow,
+// where nil means "all runes".
+func bothCharsets(a, b func(rune) bool) func(rune) bool {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	return func(r rune) bool { return a(r) && b(r) }
+}
+
+// minLimit returns the stricter of two limits, where 0 means "no limit".
+func minLimit(a, b int) int {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,92 @@
+package internal
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookupProfile(t *testing.T) {
+	for _, p := range Profiles {
+		profile, err := LookupProfile(p.Name)
+		assert.NoError(t, err)
+		assert.Equal(t, p.Name, profile.Name)
+		assert.NotEmpty(t, profile.Rules, p.Name)
+	}
+	_, err := LookupProfile("smb+ntfs")
+	assert.ErrorContains(t, err, "unknown profile 'ntfs'")
+}
+
+func TestProfileRules(t *testing.T) {
+	sanitize := func(profileName, name string) string {
+		profile, err := LookupProfile(profileName)
+		assert.NoError(t, err)
+		return NewSanitizer(profile.Rules...).Sanitize(name)
+	}
+	assert.Equal(t, "Raetsel_ 1_2.mp3", sanitize("smb", "Rätsel? 1|2.mp3"))
+	assert.Equal(t, "Rätsel_ 1_2_3.mp3", sanitize("windows", "Rätsel? 1|2*3.mp3"))
+	assert.Equal(t, "Rätsel? 1_2.mp3", sanitize("macos", "Rätsel? 1:2.mp3"))
+	assert.Equal(t, "Rätsel_ 1_2-.mp3", sanitize("fat32", "Rätsel? 1\\2\x07.mp3"))
+	assert.Equal(t, "Rätsel_ 1_2-.mp3", sanitize("exfat", "Rätsel? 1\\2\x07.mp3"))
+	assert.Equal(t, "_Raetsel_1_2__.mp3", sanitize("posix", "-Rätsel 1+2[].mp3"))
+	assert.Equal(t, "Raetsel_ 1_2_3.mp3", sanitize("smb+windows", "Rätsel? 1|2*3.mp3"))
+}
+
+func TestStackProfiles(t *testing.T) {
+	smb, _ := LookupProfile("smb")
+	fat32, _ := LookupProfile("fat32")
+	encrypted, _ := LookupProfile("synology-encrypted")
+
+	stacked := StackProfiles(smb, fat32, encrypted)
+	assert.Equal(t, "smb+fat32+synology-encrypted", stacked.Name)
+	assert.Equal(t, 143, stacked.MaxBasenameLength)
+	assert.Equal(t, 255, stacked.MaxBasenameChars)
+	// fat32 shares "replace-control-chars" and
+	// "rewrite-windows-reserved-names" with smb, and synology-encrypted
+	// shares all its rules with smb
+	assert.Len(t, stacked.Rules, len(smb.Rules)+1, "duplicate rules are removed")
+	names := ruleNames(stacked.Rules)
+	fatForbidden := slices.Index(names, "replace-fat-forbidden-chars")
+	assert.Equal(t, "replace-control-chars", names[fatForbidden-1], "rules that are not built in keep their position")
+	assert.Equal(t, []string{"trim-dots-and-spaces", "rewrite-windows-reserved-names"}, names[len(names)-2:])
+
+	lookedUp, err := LookupProfile("smb+fat32+synology-encrypted")
+	assert.NoError(t, err)
+	assert.Equal(t, stacked.Name, lookedUp.Name)
+	assert.Equal(t, stacked.MaxBasenameLength, lookedUp.MaxBasenameLength)
+}
+
+func TestStackProfilesRunsFinishingRulesLast(t *testing.T) {
+	for _, name := range []string{"windows+smb", "smb+windows"} {
+		profile, err := LookupProfile(name)
+		assert.NoError(t, err)
+		names := ruleNames(profile.Rules)
+		assert.Equal(t, ruleNames(DefaultRules()), names, name)
+		s := NewSanitizer(profile.Rules...)
+		assert.Equal(t, "foo", s.Sanitize("foo…"), name)
+		assert.Equal(t, "CON_", s.Sanitize("CON…"), name)
+	}
+}
+
+func ruleNames(rules []Rule) []string {
+	var names []string
+	for _, rule := range rules {
+		names = append(names, rule.Name())
+	}
+	return names
+}
+
+func TestConfigApplyProfile(t *testing.T) {
+	windows, _ := LookupProfile("windows")
+	config := Config{MaxBasenameLength: 999999999}
+	config.ApplyProfile(windows)
+	assert.Equal(t, 999999999, config.MaxBasenameLength)
+	assert.Equal(t, 255, config.MaxBasenameChars)
+	assert.Len(t, config.Sanitizer.Rules(), len(windows.Rules))
+
+	encrypted, _ := LookupProfile("synology-encrypted")
+	config = Config{MaxBasenameLength: 100}
+	config.ApplyProfile(encrypted)
+	assert.Equal(t, 100, config.MaxBasenameLength, "stricter limits are kept")
+}
@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// normalizeCompatibility replaces compatibility forms and typographic
+// punctuation with their closest ASCII forms: smart quotes and guillemets
+// become apostrophes, fullwidth forms like "ＡＢＣ" and "：" become "ABC" and
+// ":", and special spaces like the no-break space U+00A0 and the ideographic
+// space U+3000 become regular spaces.
+//
+// The rule maps the punctuation both before and after the compatibility
+// decomposition (NFKC), which would turn the acute accent '´' into a space and
+// a combining mark, and which produces some punctuation of its own, like the
+// fraction slash of "1⁄4".
+var normalizeCompatibility Rule = compatibilityRule{}
+
+// typographicPunctuation maps punctuation that NFKC keeps, and the few
+// compatibility characters that NFKC maps to something worse.
+var typographicPunctuation = map[rune]string{
+	// quotes, primes, and guillemets, which all become apostrophes, as
+	// Windows forbids the straight double quote
+	'‘': "'", '’': "'", '‚': "'", '‛': "'", '′': "'", '‵': "'", '´': "'",
+	'‹': "'", '›': "'",
+	'“': "'", '”': "'", '„': "'", '‟': "'", '″': "'", '‶': "'", '«': "'",
+	'»': "'", '〝': "'", '〞': "'", '「': "'", '」': "'", '『': "'", '』': "'",
+	'《': "'", '》': "'", '〈': "'", '〉': "'",
+	// dashes, hyphens, and the minus sign, except for the en dash and the
+	// em dash, which "map-special-runes" replaces
+	'‐': "-", '‑': "-", '‒': "-", '―': "-", '−': "-", '⁃': "-", '⁄': "-",
+	// CJK and Arabic punctuation
+	'、': ",", '。': ".", '【': "[", '】': "]", '〔': "[", '〕': "]", '〜': "~",
+	'،': ",", '؛': ";", '؟': "?", '٪': "%", '٫': ".", '٬': ",",
+	// the micro sign, which NFKC maps to the Greek letter mu, and the
+	// Catalan 'ŀ', which NFKC maps to 'l' and a middle dot
+	'µ': "u", 'Ŀ': "L", 'ŀ': "l",
+}
+
+type compatibilityRule struct{}
+
+func (r compatibilityRule) Name() string { return "normalize-compatibility" }
+
+func (r compatibilityRule) Apply(name string) string {
+	return mapPunctuation(norm.NFKC.String(mapPunctuation(name)))
+}
+
+func mapPunctuation(name string) string {
+	var b strings.Builder
+	for _, c := range name {
+		if latin, ok := typographicPunctuation[c]; ok {
+			b.WriteString(latin)
+		} else {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,38 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeCompatibility(t *testing.T) {
+	examples := map[string]string{
+		"“Hello” ‘World’":    "'Hello' 'World'",
+		"„Zitat“ «citation»": "'Zitat' 'citation'",
+		"Don´t":              "Don't",
+		"ＡＢＣ：１２３":            "ABC:123",
+		"a\u00A0b\u3000c":    "a b c",
+		"5µm":                "5um",
+		"¼ ²":                "1-4 2",
+		"ﬁle":                "file",
+		"ｶﾀｶﾅ":               "カタカナ",
+		"「東京」、大阪。":           "'東京',大阪.",
+		"نعم، لا؟":           "نعم, لا?",
+		"x−y‐z":              "x-y-z",
+		"Paral·lel":          "Paral·lel",
+	}
+	for name, expected := range examples {
+		assert.Equal(t, expected, normalizeCompatibility.Apply(name), name)
+	}
+}
+
+func TestSanitizeNormalizesCompatibilityForms(t *testing.T) {
+	assert.Equal(t, "Foo.txt", Sanitize("Ｆｏｏ．ｔｘｔ"))
+	assert.Equal(t, "'Zitat' - It's.txt", Sanitize("“Zitat” – It’s.txt"))
+	assert.Equal(t, "5um Parallel.jpg", Sanitize("5µm Paraŀlel.jpg"))
+
+	s := NewSanitizer(DefaultRules()...)
+	assert.NoError(t, s.Remove("normalize-compatibility"))
+	assert.Equal(t, "“Zitat”", s.Sanitize("“Zitat”"), "the stage can be switched off")
+}
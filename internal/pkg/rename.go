@@ -6,6 +6,10 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
 
 	"github.com/fatih/color"
 )
@@ -14,9 +18,26 @@ func Rename(isActualRun bool, node *FsNode, config Config) error {
 	if node == nil {
 		return errors.New("node must not be nil")
 	}
+	config = config.forNode(*node)
+	// source is the node with its name decoded to UTF-8, if needed
+	source := *node
+	var decodedFrom string
+	if config.Decoder != nil {
+		decoded, charset, err := config.Decoder.Decode(node.name)
+		if err != nil {
+			// Report names that cannot be decoded rather than mangle
+			// them, but still process their children.
+			if !config.SilentMode {
+				fmt.Println(color.YellowString(printableName(node.originalPath)), "[undecodable]")
+				fmt.Println("    note:", color.YellowString(err.Error()))
+			}
+			return renameChildren(isActualRun, node, config)
+		}
+		source.name, decodedFrom = decoded, charset
+	}
 	renameAttemptsThusFar := 0
 	for renameAttemptsThusFar < config.MaxRenameAttemptsPerPath {
-		candidateName, err := sanitizeWithCounter(*node, renameAttemptsThusFar, config)
+		candidateName, err := sanitizeWithCounter(source, renameAttemptsThusFar, config)
 		if err != nil {
 			return err
 		}
@@ -24,18 +45,22 @@ func Rename(isActualRun bool, node *FsNode, config Config) error {
 		if candidateName != node.name {
 			// Safe to rename (unless the filesystem changed out-of-band in the
 			// meantime, unbeknownst to us)
-			if !node.HasSiblingOfName(candidateName) {
+			if !node.HasSiblingOfName(candidateName) && !(config.CaseInsensitive && node.hasSiblingOfNameFold(candidateName)) {
 				node.name = candidateName
 				if isActualRun {
-					err := os.Rename(node.RenamePath(), node.Path())
+					err := renamePath(node.RenamePath(), node.Path())
 					if err != nil {
 						return err
 					}
 				} else {
 					if !config.SilentMode {
 						fmt.Println(
-							color.RedString(node.originalPath),
-							"=>", color.GreenString(node.Path()))
+							color.RedString(printableName(node.originalPath)),
+							"=>", color.GreenString(printableName(node.Path())))
+						if decodedFrom != "" {
+							fmt.Println("    note:", color.YellowString("decoded name from "+decodedFrom))
+						}
+						printNotes(source.name, node.isDir, config)
 					}
 				}
 				break
@@ -43,13 +68,15 @@ func Rename(isActualRun bool, node *FsNode, config Config) error {
 		} else {
 			if !isActualRun && !config.SilentMode {
 				if node.originalPath == node.Path() {
-					fmt.Println(node.originalPath, "[unmodified]")
+					fmt.Println(printableName(node.originalPath), "[unmodified]")
+					// The name may be unmodified because of the allowlist.
+					printNotes(source.name, node.isDir, config)
 				} else {
 					// path changed because at least one parent directory has
 					// been renamed
 					fmt.Println(
-						color.RedString(node.originalPath),
-						"=>", color.GreenString(node.Path()))
+						color.RedString(printableName(node.originalPath)),
+						"=>", color.GreenString(printableName(node.Path())))
 				}
 			}
 			break
@@ -58,36 +85,126 @@ func Rename(isActualRun bool, node *FsNode, config Config) error {
 	}
 
 	if renameAttemptsThusFar >= config.MaxRenameAttemptsPerPath {
-		return fmt.Errorf("failed to rename '%s' (no rename attempts left)", node.originalPath)
-	} else {
-		for _, child := range node.children {
-			err := Rename(isActualRun, child, config)
-			if err != nil {
-				return err
-			}
+		return fmt.Errorf("failed to rename '%s' (no rename attempts left)", printableName(node.originalPath))
+	}
+	return renameChildren(isActualRun, node, config)
+}
+
+// renamePath renames a file or directory.  Names that change in case only,
+// like "Foo" to "foo", are renamed through a temporary name, because some
+// case-insensitive file systems refuse to rename them, or ignore it.
+func renamePath(from, to string) error {
+	if !strings.EqualFold(filepath.Base(from), filepath.Base(to)) {
+		return os.Rename(from, to)
+	}
+	temp := to + ".sauber-rename"
+	for i := 1; ; i++ {
+		if _, err := os.Lstat(temp); errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		temp = fmt.Sprintf("%s.sauber-rename-%d", to, i)
+	}
+	if err := os.Rename(from, temp); err != nil {
+		return err
+	}
+	return os.Rename(temp, to)
+}
+
+func renameChildren(isActualRun bool, node *FsNode, config Config) error {
+	for _, child := range node.children {
+		err := Rename(isActualRun, child, config)
+		if err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+// printNotes prints why the name was changed, as far as the rules explain
+// their changes (see Explainer).
+func printNotes(name string, isDir bool, config Config) {
+	for _, note := range config.sanitizer().NotesEntry(name, isDir) {
+		fmt.Println("    note:", color.YellowString(note))
+	}
+}
+
 func sanitizeWithCounter(node FsNode, renameAttemptsThusFar int, config Config) (string, error) {
+	candidate, _, err := sanitizeStages(node, renameAttemptsThusFar, config)
+	return candidate, err
+}
+
+// sanitizeStages is like sanitizeWithCounter, but it also returns the stages
+// of sanitizing the name in order (see ExplainName).
+func sanitizeStages(node FsNode, renameAttemptsThusFar int, config Config) (string, []Stage, error) {
 	if renameAttemptsThusFar < 0 {
 		log.Fatalf("renameAttemptsThusFar must be >= 0, you provided %d", renameAttemptsThusFar)
 	}
 	if config.MaxBasenameLength <= 0 {
 		log.Fatalf("maxRenameAttempts must be > 0, you provided %d", config.MaxRenameAttemptsPerPath)
 	}
-	candidate := Sanitize(node.name)
-	candidate, err := truncateName(candidate, node.isDir, config.MaxBasenameLength)
+	if isSpecialName(node.name) {
+		return node.name, nil, nil
+	}
+	var stages []Stage
+	candidate := node.name
+	for _, step := range config.sanitizer().TraceEntry(node.name, node.isDir) {
+		stages = append(stages, Stage{Name: step.Rule.Name(), Before: step.Before, After: step.After, Kept: step.Kept})
+		candidate = step.After
+	}
+	if config.Reversible {
+		if renameAttemptsThusFar > 0 {
+			return "", nil, fmt.Errorf("failed to rename '%s' reversibly, because '%s' is taken", printableName(node.originalPath), candidate)
+		}
+		if len(candidate) > config.MaxBasenameLength || config.MaxBasenameChars > 0 && utf16Len(candidate) > config.MaxBasenameChars {
+			return "", nil, fmt.Errorf("failed to rename '%s' reversibly, because '%s' is too long", printableName(node.originalPath), candidate)
+		}
+	}
+	truncated, err := truncateName(candidate, node.isDir, config.MaxBasenameLength)
 	if err != nil {
-		return "", err
+		return "", nil, err
+	}
+	stages = append(stages, Stage{Name: "truncate", Before: candidate, After: truncated})
+	candidate = truncated
+	if config.MaxBasenameChars > 0 {
+		truncated, err = truncateNameChars(candidate, node.isDir, config.MaxBasenameChars)
+		if err != nil {
+			return "", nil, err
+		}
+		stages = append(stages, Stage{Name: "truncate-chars", Before: candidate, After: truncated})
+		candidate = truncated
 	}
 	if renameAttemptsThusFar > 0 {
 		digits := numDigits(config.MaxRenameAttemptsPerPath - 1)
-		formatString := fmt.Sprintf("%%s_%%0%dd", digits)
-		candidate = fmt.Sprintf(formatString, candidate, renameAttemptsThusFar)
+		formatString := fmt.Sprintf("%%s_%%0%dd%%s", digits)
+		// The suffix goes before the extension, as in "foo_1.txt", so that
+		// the file type is kept.  Hidden files like ".bashrc" have no
+		// extension.
+		stem, extension := splitExtension(candidate, node.isDir)
+		if stem == "" {
+			stem, extension = candidate, ""
+		}
+		suffixed := fmt.Sprintf(formatString, stem, renameAttemptsThusFar, extension)
+		stages = append(stages, Stage{Name: "collision-suffix", Before: candidate, After: suffixed})
+		candidate = suffixed
 	}
-	return candidate, nil
+	if config.Charset != nil {
+		charset := config.Charset
+		if allowlist := config.sanitizer().Allowlist(); allowlist != nil {
+			charset = func(r rune) bool { return config.Charset(r) || allowlist.Allows(r) }
+		}
+		if err := checkCharset(candidate, charset); err != nil {
+			return "", nil, fmt.Errorf("failed to rename '%s', because %s", printableName(node.originalPath), err.Error())
+		}
+	}
+	return candidate, stages, nil
+}
+
+// isSpecialName reports whether the name of a node is not a name that can be
+// sanitized: "." and ".." refer to directories relative to others, and the
+// root of a file system like "/" has no name at all.  Find gives the root node
+// such a name if its path is, say, ".".
+func isSpecialName(name string) bool {
+	return name == "." || name == ".." || strings.ContainsAny(name, "/"+string(filepath.Separator))
 }
 
 func numDigits(n int) int {
@@ -103,30 +220,96 @@ func numDigits(n int) int {
 }
 
 func truncateName(name string, isDir bool, maxBasenameLength int) (string, error) {
+	return truncateNameIn(name, isDir, maxBasenameLength, false)
+}
+
+// truncateNameChars is like truncateName, but it measures names in UTF-16 code
+// units rather than in bytes (see utf16Len).
+func truncateNameChars(name string, isDir bool, maxBasenameChars int) (string, error) {
+	return truncateNameIn(name, isDir, maxBasenameChars, true)
+}
+
+func truncateNameIn(name string, isDir bool, maxBasenameLength int, inChars bool) (string, error) {
 	if maxBasenameLength < 1 {
 		return "", fmt.Errorf("maxBasenameLength must be >= 1, you provided %d", maxBasenameLength)
 	}
-	if len(name) <= maxBasenameLength {
+	length := func(s string) int {
+		if inChars {
+			return utf16Len(s)
+		}
+		return len(s)
+	}
+	if length(name) <= maxBasenameLength {
 		return name, nil
 	} else {
-		if isDir {
-			return name[:maxBasenameLength], nil
-		} else {
-			extension := filepath.Ext(name)
-			if extension != "" {
-				if len(extension) > maxBasenameLength {
-					return "",
-						fmt.Errorf("could not truncate name '%s' to %d characters while preserving file extension '%s'",
-							name,
-							maxBasenameLength,
-							extension)
-				} else {
-					nameWithoutExtension := name[:maxBasenameLength-len(extension)]
-					return nameWithoutExtension + extension, nil
-				}
+		_, extension := splitExtension(name, isDir)
+		if extension != "" {
+			if length(extension) > maxBasenameLength {
+				return "",
+					fmt.Errorf("could not truncate name '%s' to %d characters while preserving file extension '%s'",
+						name,
+						maxBasenameLength,
+						extension)
 			} else {
-				return name[:maxBasenameLength], nil
+				nameWithoutExtension := prefix(name, maxBasenameLength-length(extension), inChars)
+				return nameWithoutExtension + extension, nil
+			}
+		} else {
+			return prefix(name, maxBasenameLength, inChars), nil
+		}
+	}
+}
+
+// splitExtension splits the name of a file into its stem and its extension,
+// like ".mp3".  The names of directories have no extension.
+func splitExtension(name string, isDir bool) (string, string) {
+	if isDir {
+		return name, ""
+	}
+	extension := filepath.Ext(name)
+	return name[:len(name)-len(extension)], extension
+}
+
+// prefix returns the first n bytes or, if inChars is true, the first n
+// UTF-16 code units of s.  Multi-byte characters are never split, and neither
+// are characters and their combining marks, as in names in NFD.
+func prefix(s string, n int, inChars bool) string {
+	if inChars {
+		for i, r := range s {
+			size := max(utf16.RuneLen(r), 1)
+			if size > n {
+				return s[:combiningStart(s, i)]
 			}
+			n -= size
+		}
+		return s
+	}
+	for n > 0 && n < len(s) && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:combiningStart(s, n)]
+}
+
+// utf16Len returns the length of s in UTF-16 code units, which is how Windows
+// and FAT file systems count the characters of names.  Characters outside the
+// Basic Multilingual Plane, like most emoji, count twice.
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += max(utf16.RuneLen(r), 1)
+	}
+	return n
+}
+
+// combiningStart moves the index i of s back to the start of the character
+// whose combining marks follow it, if any.
+func combiningStart(s string, i int) int {
+	for i > 0 {
+		if r, _ := utf8.DecodeRuneInString(s[i:]); !unicode.Is(unicode.Mn, r) {
+			break
 		}
+		_, size := utf8.DecodeLastRuneInString(s[:i])
+		i -= size
 	}
+	return i
 }
@@ -1,11 +1,93 @@
 package internal
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+func TestRenameResolvesCollisionsOfRewrittenNames(t *testing.T) {
+	root := FsNode{
+		name:         "share",
+		originalPath: "share",
+		isDir:        true,
+	}
+	root.AddNestedChild("share/CON.txt", false)
+	root.AddNestedChild("share/CON_.txt", false)
+	root.AddNestedChild("share/nul", true)
+	root.AddNestedChild("share/nul/AUX", false)
+	config := Config{
+		MaxRenameAttemptsPerPath: 10,
+		MaxBasenameLength:        255,
+		SilentMode:               true,
+	}
+	assert.NoError(t, Rename(false, &root, config))
+	expected := []string{
+		"share",
+		"share/CON__1.txt",
+		"share/CON_.txt",
+		"share/nul_",
+		"share/nul_/AUX_",
+	}
+	assert.Equal(t, expected, root.Paths())
+}
+
+func TestRenameAppendsSuffixBeforeExtension(t *testing.T) {
+	rule, err := NewCaseRule("lower")
+	require.NoError(t, err)
+	root := FsNode{name: "share", originalPath: "share", isDir: true}
+	root.AddNestedChild("share/Foo.TXT", false)
+	root.AddNestedChild("share/foo.txt", false)
+	root.AddNestedChild("share/.Bashrc", false)
+	root.AddNestedChild("share/.bashrc", false)
+	root.AddNestedChild("share/Notes", true)
+	root.AddNestedChild("share/notes", true)
+	config := Config{
+		MaxRenameAttemptsPerPath: 100,
+		MaxBasenameLength:        255,
+		SilentMode:               true,
+		Sanitizer:                NewSanitizer(rule),
+	}
+	assert.NoError(t, Rename(false, &root, config))
+	expected := []string{
+		"share",
+		"share/foo_01.txt",
+		"share/foo.txt",
+		"share/.bashrc_01",
+		"share/.bashrc",
+		"share/notes_01",
+		"share/notes",
+	}
+	assert.Equal(t, expected, root.Paths())
+}
+
+func TestRenameKeepsSpecialNamesOfRoot(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "Urtümlich"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "Urtümlich", "Ärger.mp3"), nil, 0o644))
+	t.Chdir(dir)
+	config := Config{
+		MaxRenameAttemptsPerPath: 10,
+		MaxBasenameLength:        255,
+		SilentMode:               true,
+	}
+	root, err := Find(".", DefaultSkipDirectories)
+	assert.NoError(t, err)
+	assert.NoError(t, Rename(true, root, config))
+	assert.Equal(t, []string{".", "Urtuemlich", "Urtuemlich/Aerger.mp3"}, root.Paths())
+	_, err = os.Stat(filepath.Join(dir, "Urtuemlich", "Aerger.mp3"))
+	assert.NoError(t, err)
+
+	for _, name := range []string{".", "..", "/"} {
+		root := FsNode{name: name, originalPath: name, isDir: true}
+		assert.NoError(t, Rename(false, &root, config))
+		assert.Equal(t, []string{name}, root.Paths(), "special names are kept")
+	}
+}
+
 func TestTruncateName(t *testing.T) {
 	var s string
 
@@ -35,6 +117,43 @@ func TestTruncateName(t *testing.T) {
 	assert.Error(t, err3)
 }
 
+func TestTruncateNameChars(t *testing.T) {
+	var s string
+
+	s, _ = truncateNameChars("Größe", true, 4)
+	assert.Equal(t, "Größ", s)
+	s, _ = truncateNameChars("Größe.mp3", false, 7)
+	assert.Equal(t, "Grö.mp3", s)
+	s, _ = truncateNameChars("Größe.mp3", false, 9)
+	assert.Equal(t, "Größe.mp3", s)
+
+	_, err := truncateNameChars("Größe.mp3", false, 3)
+	assert.Error(t, err)
+
+	// Characters outside the Basic Multilingual Plane are two UTF-16 code
+	// units, like Windows counts them.
+	s, _ = truncateNameChars("a😀b😀", true, 4)
+	assert.Equal(t, "a😀b", s)
+	s, _ = truncateNameChars("a😀b😀", true, 2)
+	assert.Equal(t, "a", s)
+	assert.Equal(t, 6, utf16Len("a😀b😀"))
+}
+
+func TestTruncateNameDoesNotSplitCharacters(t *testing.T) {
+	// 'ö' and 'ß' are encoded as two bytes each in UTF-8
+	s, _ := truncateName("Größe", true, 5)
+	assert.Equal(t, "Grö", s)
+	s, _ = truncateName("Größe.mp3", false, 9)
+	assert.Equal(t, "Grö.mp3", s)
+	// In NFD, 'ö' is 'o' followed by the combining diaeresis U+0308
+	s, _ = truncateName("Gro\u0308\u00dfe", true, 4)
+	assert.Equal(t, "Gr", s)
+	s, _ = truncateNameChars("Gro\u0308\u00dfe", true, 3)
+	assert.Equal(t, "Gr", s)
+	s, _ = truncateNameChars("Gro\u0308\u00dfe", true, 4)
+	assert.Equal(t, "Gro\u0308", s)
+}
+
 func TestNumDigits(t *testing.T) {
 	assert.Equal(t, 1, numDigits(0))
 	assert.Equal(t, 1, numDigits(3))
@@ -44,3 +163,34 @@ func TestNumDigits(t *testing.T) {
 	assert.Equal(t, 3, numDigits(123))
 	assert.Equal(t, 3, numDigits(-123))
 }
+
+func TestRenameUsesSanitizerOfSubtree(t *testing.T) {
+	root := FsNode{
+		name:         "music",
+		originalPath: "music",
+		isDir:        true,
+	}
+	root.AddNestedChild("music/Ärger.mp3", false)
+	root.AddNestedChild("music/svenska", true)
+	root.AddNestedChild("music/svenska/Mälaren", true)
+	root.AddNestedChild("music/svenska/Mälaren/Ärlig.mp3", false)
+	rule, err := NewLocaleRule("sv")
+	assert.NoError(t, err)
+	swedish := NewSanitizer(DefaultRules()...)
+	assert.NoError(t, swedish.Replace("replace-umlauts", rule))
+	config := Config{
+		MaxRenameAttemptsPerPath: 10,
+		MaxBasenameLength:        255,
+		SilentMode:               true,
+		Subtrees:                 map[string]*Sanitizer{"music/svenska/": swedish},
+	}
+	assert.NoError(t, Rename(false, &root, config))
+	expected := []string{
+		"music",
+		"music/Aerger.mp3",
+		"music/svenska",
+		"music/svenska/Malaren",
+		"music/svenska/Malaren/Arlig.mp3",
+	}
+	assert.Equal(t, expected, root.Paths())
+}
@@ -0,0 +1,124 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// reversibleMarker marks the names that the rule "encode-reversibly" changed.
+// Every '%' that the encoding writes is followed by two hex digits, so the
+// marker cannot be mistaken for an escape.
+const reversibleMarker = "%_"
+
+// NewReversibleRule returns the rule "encode-reversibly", which encodes every
+// character that is not printable ASCII, or that the charset does not allow,
+// as the percent-encoded bytes of its UTF-8, as in URLs: "Müller" becomes
+// "M%C3%BCller%_", so that it does not collide with "Mueller".  '%' itself
+// always becomes "%25".  Leading spaces, trailing dots and spaces, and the
+// last letter of names that Windows reserves, like "CON", are encoded, too.
+// A nil charset allows all printable ASCII characters.
+//
+// Unlike transliteration, the encoding is reversible: DecodeName returns the
+// original name.  Names that the rule changes are marked with "%_" before
+// their extension, so that sanitizing them again leaves them alone: a name
+// with the marker is taken as encoded already if decoding and encoding it
+// again gives the same name.  Other names, including names with escapes like
+// "%C3%A9" that were never encoded, are encoded as usual.
+func NewReversibleRule(charset func(rune) bool) (Rule, error) {
+	allowed := StrictASCIICharset(charset)
+	for _, c := range "%_0123456789ABCDEF" {
+		if !allowed(c) {
+			return nil, fmt.Errorf("reversible encoding writes '%c', which the target system does not allow", c)
+		}
+	}
+	return reversibleRule{allowed: allowed}, nil
+}
+
+type reversibleRule struct {
+	allowed func(rune) bool
+}
+
+func (r reversibleRule) Name() string { return "encode-reversibly" }
+
+func (r reversibleRule) Apply(name string) string {
+	if strings.Contains(name, reversibleMarker) {
+		if decoded, err := DecodeName(name); err == nil && r.encode(decoded) == name {
+			return name
+		}
+	}
+	return r.encode(name)
+}
+
+func (r reversibleRule) encode(name string) string {
+	// the positions of the bytes to encode regardless of the charset
+	encode := map[int]bool{}
+	if trimmed := strings.TrimLeft(name, " "); trimmed != name {
+		for i := range len(name) - len(trimmed) {
+			encode[i] = true
+		}
+	}
+	for i := len(name) - 1; i >= 0 && (name[i] == '.' || name[i] == ' '); i-- {
+		if strings.Trim(name[:i], ".") == "" && name[i] == '.' {
+			// the leading dots of hidden files, like in ".bashrc"
+			break
+		}
+		encode[i] = true
+	}
+	if m := windowsReservedName.FindStringSubmatchIndex(name); m != nil {
+		_, size := utf8.DecodeLastRuneInString(name[:m[3]])
+		encode[m[3]-size] = true
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(name); {
+		c, size := utf8.DecodeRuneInString(name[i:])
+		if c == '%' || encode[i] || !r.allowed(c) || c == utf8.RuneError && size == 1 {
+			for _, x := range []byte(name[i : i+size]) {
+				_, _ = fmt.Fprintf(&b, "%%%02X", x)
+			}
+		} else {
+			b.WriteString(name[i : i+size])
+		}
+		i += size
+	}
+	encoded := b.String()
+	if encoded == name {
+		return name
+	}
+	// The marker goes before the extension, so that the file type is kept,
+	// but not before the whole name of a hidden file like ".b%C3%A4shrc".
+	stem, extension := splitExtension(encoded, false)
+	if stem == "" {
+		stem, extension = encoded, ""
+	}
+	return stem + reversibleMarker + extension
+}
+
+func (r reversibleRule) Explain(before, after string) string {
+	return "encoded characters reversibly and marked the name with \"" + reversibleMarker + "\", which `sauber --decode` decodes"
+}
+
+// DecodeName returns the original name of a name that the rule
+// "encode-reversibly" encoded (see NewReversibleRule).  Names without the
+// marker "%_" were not changed by the rule, and are returned as they are.
+func DecodeName(name string) (string, error) {
+	switch strings.Count(name, reversibleMarker) {
+	case 0:
+		return name, nil
+	case 1:
+	default:
+		return "", fmt.Errorf("name '%s' is not encoded reversibly: more than one marker '%s'", name, reversibleMarker)
+	}
+	decoded, err := url.PathUnescape(strings.Replace(name, reversibleMarker, "", 1))
+	if err != nil {
+		var escapeErr url.EscapeError
+		if errors.As(err, &escapeErr) {
+			return "", fmt.Errorf("name '%s' is not encoded reversibly: invalid escape '%s'", name, string(escapeErr))
+		}
+		return "", err
+	}
+	return decoded, nil
+}
@@ -0,0 +1,89 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReversibleRule(t *testing.T) {
+	rule, err := NewReversibleRule(windowsRune)
+	require.NoError(t, err)
+	names := map[string]string{
+		"Müller.txt":       "M%C3%BCller%_.txt",
+		"Mueller.txt":      "Mueller.txt",
+		"100%.txt":         "100%25%_.txt",
+		"Rätsel? 1|2.mp3":  "R%C3%A4tsel%3F 1%7C2%_.mp3",
+		"東京":               "%E6%9D%B1%E4%BA%AC%_",
+		" foo. ":           "%20foo%2E%20%_",
+		".bashrc":          ".bashrc",
+		".bäshrc":          ".b%C3%A4shrc%_",
+		"...":              "...",
+		"CON.txt":          "CO%4E%_.txt",
+		"lpt1":             "lpt%31%_",
+		"a\x07b":           "a%07b%_",
+		"R\xe4tsel":        "R%E4tsel%_",
+		"Mu\u0308ller.txt": "Mu%CC%88ller%_.txt",
+		"100%41.txt":       "100%2541%_.txt",
+		"a%20b%2e":         "a%2520b%252e%_",
+		"Caf%C3%A9.pdf":    "Caf%25C3%25A9%_.pdf",
+		"50%_off.txt":      "50%25_off%_.txt",
+	}
+	for name, encoded := range names {
+		assert.Equal(t, encoded, rule.Apply(name), printableName(name))
+		assert.Equal(t, encoded, rule.Apply(encoded), "encoding twice gives the same name: %s", printableName(name))
+		decoded, err := DecodeName(encoded)
+		assert.NoError(t, err)
+		assert.Equal(t, name, decoded, "the encoding is reversible")
+	}
+
+	posix, err := NewReversibleRule(isPortable)
+	assert.Nil(t, posix)
+	assert.ErrorContains(t, err, "reversible encoding writes '%'")
+}
+
+func TestDecodeName(t *testing.T) {
+	decoded, err := DecodeName("100%.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "100%.txt", decoded, "names without the marker were not encoded")
+	_, err = DecodeName("100%%_.txt")
+	assert.ErrorContains(t, err, "name '100%%_.txt' is not encoded reversibly: invalid escape '%.t'")
+	_, err = DecodeName("a%_b%_")
+	assert.ErrorContains(t, err, "name 'a%_b%_' is not encoded reversibly: more than one marker '%_'")
+}
+
+func TestRenameReversibly(t *testing.T) {
+	smb, _ := LookupProfile("smb")
+	config := Config{
+		MaxRenameAttemptsPerPath: 10,
+		MaxBasenameLength:        20,
+		SilentMode:               true,
+	}
+	assert.NoError(t, config.ApplyReversible(smb))
+	root := FsNode{name: "share", originalPath: "share", isDir: true}
+	root.AddNestedChild("share/Müller", true)
+	root.AddNestedChild("share/Mueller", true)
+	root.AddNestedChild("share/Müller/Rätsel?.mp3", false)
+	assert.NoError(t, Rename(false, &root, config))
+	expected := []string{
+		"share",
+		"share/M%C3%BCller%_",
+		"share/M%C3%BCller%_/R%C3%A4tsel%3F%_.mp3",
+		"share/Mueller",
+	}
+	assert.Equal(t, expected, root.Paths())
+	assert.NoError(t, Rename(false, &root, config))
+	assert.Equal(t, expected, root.Paths(), "renaming twice changes nothing")
+
+	// A name that merely looks encoded is encoded like any other.
+	root = FsNode{name: "share", originalPath: "share", isDir: true}
+	root.AddNestedChild("share/Café.pdf", false)
+	root.AddNestedChild("share/Caf%C3%A9.pdf", false)
+	assert.NoError(t, Rename(false, &root, config))
+	assert.Equal(t, []string{"share", "share/Caf%C3%A9%_.pdf", "share/Caf%25C3%25A9%_.pdf"}, root.Paths())
+
+	root = FsNode{name: "share", originalPath: "share", isDir: true}
+	root.AddNestedChild("share/Übermütige Überraschung.mp3", false)
+	assert.ErrorContains(t, Rename(false, &root, config), "failed to rename 'share/Übermütige Überraschung.mp3' reversibly, because")
+}
@@ -0,0 +1,353 @@
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Rule is a single step of a Sanitizer's pipeline.  A rule receives the
+// output of the previous rule and returns its (possibly) rewritten version.
+type Rule interface {
+	// Name identifies the rule, e.g., in the output of `sauber --list-rules`.
+	Name() string
+	// Apply returns the rewritten name.
+	Apply(name string) string
+}
+
+// Explainer is implemented by rules that explain their changes to a name,
+// e.g., in the output of a dry run.
+type Explainer interface {
+	// Explain describes why the rule changed the name from before to after.
+	Explain(before, after string) string
+}
+
+// EntryRule is implemented by rules that treat the names of files and of
+// directories differently, e.g. to keep the extensions of files intact.  The
+// sanitizer calls ApplyEntry rather than Apply for such rules (see
+// Sanitizer.SanitizeEntry).
+type EntryRule interface {
+	// ApplyEntry returns the rewritten name of a file or, if isDir is true,
+	// of a directory.
+	ApplyEntry(name string, isDir bool) string
+}
+
+// Sanitizer sanitizes names by running them through an ordered list of rules.
+type Sanitizer struct {
+	rules     []Rule
+	allowlist *Allowlist
+}
+
+// NewSanitizer returns a sanitizer that applies the given rules in order.
+func NewSanitizer(rules ...Rule) *Sanitizer {
+	return &Sanitizer{rules: append([]Rule(nil), rules...)}
+}
+
+// Rules returns the sanitizer's rules in the order in which they are applied.
+func (s *Sanitizer) Rules() []Rule {
+	return append([]Rule(nil), s.rules...)
+}
+
+// NoRuleError is returned by a sanitizer that has no rule of the given name,
+// e.g. because a profile does without that rule.
+type NoRuleError struct {
+	Name string
+}
+
+func (e *NoRuleError) Error() string {
+	return fmt.Sprintf("no sanitize rule named '%s'", e.Name)
+}
+
+// Replace replaces the rule of the given name with another rule.
+func (s *Sanitizer) Replace(name string, rule Rule) error {
+	for i, r := range s.rules {
+		if r.Name() == name {
+			s.rules[i] = rule
+			return nil
+		}
+	}
+	return &NoRuleError{Name: name}
+}
+
+// InsertBefore inserts the rule before the rule of the given name.
+func (s *Sanitizer) InsertBefore(name string, rule Rule) error {
+	for i, r := range s.rules {
+		if r.Name() == name {
+			s.rules = append(s.rules[:i], append([]Rule{rule}, s.rules[i:]...)...)
+			return nil
+		}
+	}
+	return &NoRuleError{Name: name}
+}
+
+// Remove removes the rule of the given name.
+func (s *Sanitizer) Remove(name string) error {
+	for i, r := range s.rules {
+		if r.Name() == name {
+			s.rules = append(s.rules[:i], s.rules[i+1:]...)
+			return nil
+		}
+	}
+	return &NoRuleError{Name: name}
+}
+
+// SetAllowlist makes all rules of the sanitizer keep the characters of the
+// allowlist, or lets them change all characters again if the allowlist is
+// nil.
+func (s *Sanitizer) SetAllowlist(allowlist *Allowlist) {
+	s.allowlist = allowlist
+}
+
+// Allowlist returns the allowlist of the sanitizer, or nil.
+func (s *Sanitizer) Allowlist() *Allowlist {
+	return s.allowlist
+}
+
+// Sanitize runs the name through all rules of the sanitizer.  The name is
+// treated as the name of a file (see SanitizeEntry).
+func (s *Sanitizer) Sanitize(name string) string {
+	return s.SanitizeEntry(name, false)
+}
+
+// SanitizeEntry runs the name of a file or, if isDir is true, of a directory
+// through all rules of the sanitizer.
+func (s *Sanitizer) SanitizeEntry(name string, isDir bool) string {
+	for _, rule := range s.rules {
+		name, _ = s.apply(rule, name, isDir)
+	}
+	return name
+}
+
+func (s *Sanitizer) apply(rule Rule, name string, isDir bool) (string, []rune) {
+	apply := rule.Apply
+	if r, ok := rule.(EntryRule); ok {
+		apply = func(name string) string { return r.ApplyEntry(name, isDir) }
+	}
+	// The allowlist keeps characters, whatever their normalization form.
+	if _, ok := rule.(normalizationRule); ok || s.allowlist == nil {
+		return apply(name), nil
+	}
+	return s.allowlist.apply(apply, name)
+}
+
+// Step records the input and output of a single rule.
+type Step struct {
+	Rule   Rule
+	Before string
+	After  string
+	// Kept lists the characters of the allowlist that the rule would have
+	// changed without the allowlist (see Sanitizer.SetAllowlist).
+	Kept []rune
+}
+
+// Trace runs the name through all rules of the sanitizer, like Sanitize, and
+// returns the steps in order.  The output of the last step is the sanitized
+// name.
+func (s *Sanitizer) Trace(name string) []Step {
+	return s.TraceEntry(name, false)
+}
+
+// TraceEntry is like Trace for the name of a file or, if isDir is true, of a
+// directory (see SanitizeEntry).
+func (s *Sanitizer) TraceEntry(name string, isDir bool) []Step {
+	var steps []Step
+	for _, rule := range s.rules {
+		step := Step{Rule: rule, Before: name}
+		step.After, step.Kept = s.apply(rule, name, isDir)
+		steps = append(steps, step)
+		name = step.After
+	}
+	return steps
+}
+
+// Notes returns the explanations of all rules that changed the name (see
+// Explainer), and names the characters that the sanitizer kept because of
+// its allowlist.
+func (s *Sanitizer) Notes(name string) []string {
+	return s.NotesEntry(name, false)
+}
+
+// NotesEntry is like Notes for the name of a file or, if isDir is true, of a
+// directory (see SanitizeEntry).
+func (s *Sanitizer) NotesEntry(name string, isDir bool) []string {
+	var notes []string
+	var kept []rune
+	for _, step := range s.TraceEntry(name, isDir) {
+		if explainer, ok := step.Rule.(Explainer); ok && step.Before != step.After {
+			notes = append(notes, explainer.Explain(step.Before, step.After))
+		}
+		for _, c := range step.Kept {
+			if !slices.Contains(kept, c) {
+				kept = append(kept, c)
+			}
+		}
+	}
+	for _, c := range kept {
+		if changed := NewSanitizer(s.rules...).SanitizeEntry(string(c), isDir); changed == "" {
+			notes = append(notes, fmt.Sprintf("kept %q, which would have been removed without the allowlist", c))
+		} else {
+			notes = append(notes, fmt.Sprintf("kept %q, which would have become %q without the allowlist", c, changed))
+		}
+	}
+	return notes
+}
+
+type replaceRule struct {
+	name     string
+	replacer *strings.Replacer
+}
+
+// NewReplaceRule returns a rule that replaces strings with other strings.
+// The `oldnew` arguments are interpreted as for `strings.NewReplacer`.
+func NewReplaceRule(name string, oldnew ...string) Rule {
+	return replaceRule{name: name, replacer: strings.NewReplacer(oldnew...)}
+}
+
+func (r replaceRule) Name() string { return r.name }
+
+func (r replaceRule) Apply(name string) string {
+	return r.replacer.Replace(name)
+}
+
+type mapRule struct {
+	name    string
+	mapping func(rune) rune
+}
+
+// NewMapRule returns a rule that maps each rune of a name to another rune.
+// Runes for which the mapping returns a negative value are removed.
+func NewMapRule(name string, mapping func(rune) rune) Rule {
+	return mapRule{name: name, mapping: mapping}
+}
+
+func (r mapRule) Name() string { return r.name }
+
+func (r mapRule) Apply(name string) string {
+	return strings.Map(r.mapping, name)
+}
+
+type transliterateRule struct {
+	name  string
+	table map[rune]string
+}
+
+// NewTransliterateRule returns a rule that replaces runes with strings
+// according to the table.  Runes that are not in the table are kept.
+//
+// Uppercase replacements of several letters, like "TH" for 'Þ', are
+// title-cased when followed by a lowercase letter, so that "Þórr" becomes
+// "Thórr" rather than "THórr".
+func NewTransliterateRule(name string, table map[rune]string) Rule {
+	return transliterateRule{name: name, table: table}
+}
+
+func (r transliterateRule) Name() string { return r.name }
+
+func (r transliterateRule) Apply(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, c := range runes {
+		replacement, ok := r.table[c]
+		if !ok {
+			b.WriteRune(c)
+			continue
+		}
+		if i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			replacement = titleCase(replacement)
+		}
+		b.WriteString(replacement)
+	}
+	return b.String()
+}
+
+// matchCase uppercases the transliteration of runes[i:i+n] if runes[i] is
+// uppercase.  The result is title-cased if the word continues in lowercase, so
+// that "Щука" becomes "Shchuka", but "ЩУКА" becomes "SHCHUKA".
+func matchCase(latin string, runes []rune, i, n int) string {
+	if !unicode.IsUpper(runes[i]) {
+		return latin
+	}
+	latin = strings.ToUpper(latin)
+	if n > 1 && unicode.IsLower(runes[i+1]) || i+n < len(runes) && unicode.IsLower(runes[i+n]) {
+		latin = titleCase(latin)
+	}
+	return latin
+}
+
+// titleCase converts an all-uppercase string like "TH" to "Th".  Other
+// strings are returned unchanged.
+func titleCase(s string) string {
+	if utf8.RuneCountInString(s) < 2 || strings.ToUpper(s) != s || strings.ToLower(s) == s {
+		return s
+	}
+	first, size := utf8.DecodeRuneInString(s)
+	return string(first) + strings.ToLower(s[size:])
+}
+
+type categoryRule struct {
+	name        string
+	table       *unicode.RangeTable
+	replacement string
+}
+
+// NewCategoryRule returns a rule that replaces every rune in the given
+// Unicode range table, such as `unicode.Co` (private use), with the
+// replacement.  An empty replacement removes the runes.
+func NewCategoryRule(name string, table *unicode.RangeTable, replacement string) Rule {
+	return categoryRule{name: name, table: table, replacement: replacement}
+}
+
+func (r categoryRule) Name() string { return r.name }
+
+func (r categoryRule) Apply(name string) string {
+	var b strings.Builder
+	for _, c := range name {
+		if unicode.Is(r.table, c) {
+			b.WriteString(r.replacement)
+		} else {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+type normalizeRule struct {
+	name string
+	form norm.Form
+}
+
+// NewNormalizeRule returns a rule that converts a name to the given Unicode
+// normalization form.
+func NewNormalizeRule(name string, form norm.Form) Rule {
+	return normalizeRule{name: name, form: form}
+}
+
+func (r normalizeRule) Name() string { return r.name }
+
+func (r normalizeRule) Apply(name string) string {
+	return r.form.String(name)
+}
+
+type regexRule struct {
+	name        string
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// NewRegexRule returns a rule that replaces all matches of the pattern.
+// Inside the replacement, `$1` etc. are expanded as for
+// `regexp.Regexp.ReplaceAllString`.
+func NewRegexRule(name string, pattern *regexp.Regexp, replacement string) Rule {
+	return regexRule{name: name, pattern: pattern, replacement: replacement}
+}
+
+func (r regexRule) Name() string { return r.name }
+
+func (r regexRule) Apply(name string) string {
+	return r.pattern.ReplaceAllString(name, r.replacement)
+}
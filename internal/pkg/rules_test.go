@@ -0,0 +1,138 @@
+package internal
+
+import (
+	"regexp"
+	"testing"
+	"unicode"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/unicode/norm"
+)
+
+func TestSanitizerAppliesRulesInOrder(t *testing.T) {
+	s := NewSanitizer(
+		NewReplaceRule("a-to-b", "a", "b"),
+		NewReplaceRule("b-to-c", "b", "c"),
+	)
+	assert.Equal(t, "cc", s.Sanitize("ab"))
+
+	s = NewSanitizer(
+		NewReplaceRule("b-to-c", "b", "c"),
+		NewReplaceRule("a-to-b", "a", "b"),
+	)
+	assert.Equal(t, "bc", s.Sanitize("ab"))
+}
+
+func TestSanitizerWithoutRulesKeepsName(t *testing.T) {
+	assert.Equal(t, "Ähnlich", NewSanitizer().Sanitize("Ähnlich"))
+}
+
+func TestSanitizerRulesIsACopy(t *testing.T) {
+	s := NewSanitizer(DefaultRules()...)
+	rules := s.Rules()
+	rules[0] = NewReplaceRule("noop")
+	assert.Equal(t, "repair-mojibake", s.Rules()[0].Name())
+}
+
+func TestSanitizerReplace(t *testing.T) {
+	s := NewSanitizer(NewReplaceRule("x", "a", "b"), NewReplaceRule("y", "b", "c"))
+	assert.NoError(t, s.Replace("x", NewReplaceRule("z", "a", "d")))
+	assert.Equal(t, "dc", s.Sanitize("ab"))
+	assert.Error(t, s.Replace("x", NewReplaceRule("z", "a", "d")))
+}
+
+func TestSanitizerInsertBefore(t *testing.T) {
+	s := NewSanitizer(NewReplaceRule("x", "a", "b"), NewReplaceRule("y", "b", "c"))
+	assert.NoError(t, s.InsertBefore("y", NewReplaceRule("z", "c", "d")))
+	assert.Equal(t, "cd", s.Sanitize("ac"))
+	assert.Equal(t, "z", s.Rules()[1].Name())
+	assert.Error(t, s.InsertBefore("w", NewReplaceRule("z", "c", "d")))
+}
+
+func TestSanitizerRemove(t *testing.T) {
+	s := NewSanitizer(NewReplaceRule("x", "a", "b"), NewReplaceRule("y", "b", "c"))
+	assert.NoError(t, s.Remove("y"))
+	assert.Equal(t, "bb", s.Sanitize("ab"))
+	assert.Len(t, s.Rules(), 1)
+	assert.Error(t, s.Remove("y"))
+}
+
+func TestSanitizerTrace(t *testing.T) {
+	s := NewSanitizer(NewReplaceRule("x", "a", "b"), NewReplaceRule("y", "c", "d"))
+	steps := s.Trace("ab")
+	assert.Len(t, steps, 2)
+	assert.Equal(t, "x", steps[0].Rule.Name())
+	assert.Equal(t, "ab", steps[0].Before)
+	assert.Equal(t, "bb", steps[0].After)
+	assert.Equal(t, "bb", steps[1].Before)
+	assert.Equal(t, "bb", steps[1].After)
+	assert.Equal(t, s.Sanitize("ab"), steps[len(steps)-1].After)
+}
+
+func TestSanitizerNotes(t *testing.T) {
+	s := NewSanitizer(NewReplaceRule("x", "a", "b"), NewTrimRule(""))
+	assert.Empty(t, s.Notes("a"), "rules that are no Explainer have no notes")
+	assert.Empty(t, s.Notes("foo"), "rules that change nothing have no notes")
+	assert.Len(t, s.Notes("foo."), 1)
+}
+
+func TestDefaultRuleNames(t *testing.T) {
+	var names []string
+	for _, rule := range DefaultRules() {
+		names = append(names, rule.Name())
+	}
+	expected := []string{
+		"repair-mojibake",
+		"replace-symbols",
+		"normalize-compatibility",
+		"fold-confusables",
+		"replace-umlauts",
+		"collapse-punctuation",
+		"transliterate-cyrillic",
+		"transliterate-greek",
+		"transliterate-scripts",
+		"decompose-nfd",
+		"remove-nonspacing-marks",
+		"transliterate-latin",
+		"map-special-runes",
+		"compose-nfc",
+		"replace-control-chars",
+		"replace-invisible-chars",
+		"replace-private-use-chars",
+		"replace-windows-forbidden-chars",
+		"trim-dots-and-spaces",
+		"rewrite-windows-reserved-names",
+	}
+	assert.Equal(t, expected, names)
+}
+
+func TestMapRule(t *testing.T) {
+	rule := NewMapRule("x", func(r rune) rune {
+		switch r {
+		case 'a':
+			return 'b'
+		case 'c':
+			return -1
+		}
+		return r
+	})
+	assert.Equal(t, "x", rule.Name())
+	assert.Equal(t, "bbd", rule.Apply("abcd"))
+}
+
+func TestCategoryRule(t *testing.T) {
+	assert.Equal(t, "x--y", NewCategoryRule("x", unicode.Co, "-").Apply("xy"))
+	assert.Equal(t, "xy", NewCategoryRule("x", unicode.Co, "").Apply("xy"))
+}
+
+func TestNormalizeRule(t *testing.T) {
+	decomposed := NewNormalizeRule("x", norm.NFD).Apply("ä")
+	assert.Equal(t, "ä", decomposed)
+	assert.Equal(t, "ä", NewNormalizeRule("x", norm.NFC).Apply(decomposed))
+}
+
+func TestRegexRule(t *testing.T) {
+	rule := NewRegexRule("x", regexp.MustCompile(`^Track (\d+) - (.*)$`), "${1}_$2")
+	assert.Equal(t, "01_Intro.mp3", rule.Apply("Track 01 - Intro.mp3"))
+	assert.Equal(t, "Intro.mp3", rule.Apply("Intro.mp3"))
+}
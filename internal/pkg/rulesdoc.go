@@ -0,0 +1,325 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/pelletier/go-toml/v2/unstable"
+)
+
+// docNode is a value of a parsed rules file, together with the line on which
+// it was defined.  Both the TOML and the JSON flavor of rules files are
+// parsed into docNodes, so that validation (see rulesfile.go) can report line
+// numbers regardless of the file format.
+type docNode struct {
+	line   int
+	kind   docKind
+	str    string
+	items  []*docNode          // elements of a docArray
+	keys   []string            // keys of a docTable, in document order
+	fields map[string]*docNode // values of a docTable
+	// implicit reports whether a docTable was only created by a dotted key
+	// or header, like the table "a" by `[a.b]`, and may still be defined.
+	implicit bool
+}
+
+type docKind int
+
+const (
+	docString docKind = iota
+	docBool
+	docArray
+	docTable
+)
+
+func (k docKind) String() string {
+	switch k {
+	case docString:
+		return "string"
+	case docBool:
+		return "boolean"
+	case docArray:
+		return "array"
+	default:
+		return "table"
+	}
+}
+
+func newDocTable(line int) *docNode {
+	return &docNode{line: line, kind: docTable, fields: map[string]*docNode{}}
+}
+
+func (node *docNode) set(key string, value *docNode) error {
+	if existing, ok := node.fields[key]; ok {
+		return fmt.Errorf("line %d: duplicate key %q (first defined on line %d)",
+			value.line, key, existing.line)
+	}
+	node.keys = append(node.keys, key)
+	node.fields[key] = value
+	return nil
+}
+
+// parseTOMLDoc parses a TOML rules file.  The syntax is left to go-toml's
+// parser, which reports where each key and value is defined; parseTOMLDoc
+// only builds docNodes from its syntax tree and rejects values that rules
+// files have no use for, like numbers and dates.
+func parseTOMLDoc(data []byte) (*docNode, error) {
+	var p unstable.Parser
+	p.Reset(data)
+	root := newDocTable(1)
+	current := root
+	for p.NextExpression() {
+		expr := p.Expression()
+		var err error
+		switch expr.Kind {
+		case unstable.Table, unstable.ArrayTable:
+			current, err = parseTOMLHeader(&p, root, expr)
+		case unstable.KeyValue:
+			err = parseTOMLKeyValue(&p, current, expr)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := p.Error(); err != nil {
+		var parserErr *unstable.ParserError
+		if errors.As(err, &parserErr) {
+			line := p.Shape(p.Range(parserErr.Highlight)).Start.Line
+			return nil, fmt.Errorf("line %d: %s", line, parserErr.Message)
+		}
+		return nil, err
+	}
+	return root, nil
+}
+
+// tomlLine returns the line on which the node starts.  go-toml does not
+// record where arrays and tables start, so they get the line of their key,
+// which is passed as fallback.
+func tomlLine(p *unstable.Parser, node *unstable.Node, fallback int) int {
+	switch {
+	case node.Raw.Length > 0:
+		return p.Shape(node.Raw).Start.Line
+	case node.Kind == unstable.Bool:
+		return p.Shape(p.Range(node.Data)).Start.Line
+	default:
+		return fallback
+	}
+}
+
+// tomlKey returns the parts of the (possibly dotted) key of a key-value pair
+// or table header, and the line on which the key is defined.
+func tomlKey(p *unstable.Parser, node *unstable.Node) ([]string, int) {
+	var parts []string
+	line := 0
+	for it := node.Key(); it.Next(); {
+		if line == 0 {
+			line = tomlLine(p, it.Node(), 0)
+		}
+		parts = append(parts, string(it.Node().Data))
+	}
+	return parts, line
+}
+
+// tomlTable returns the table that the dotted key leads to from the table,
+// creating the tables on the way that do not exist yet.
+func tomlTable(table *docNode, keys []string, line int) (*docNode, error) {
+	for _, key := range keys {
+		next, ok := table.fields[key]
+		switch {
+		case !ok:
+			next = newDocTable(line)
+			next.implicit = true
+			if err := table.set(key, next); err != nil {
+				return nil, err
+			}
+		case next.kind == docArray && len(next.items) > 0 && next.items[len(next.items)-1].kind == docTable:
+			// A dotted key into an array of tables continues in its last
+			// table.
+			next = next.items[len(next.items)-1]
+		case next.kind != docTable:
+			return nil, fmt.Errorf("line %d: %q is already defined as a %s on line %d",
+				line, key, next.kind, next.line)
+		}
+		table = next
+	}
+	return table, nil
+}
+
+func parseTOMLHeader(p *unstable.Parser, root *docNode, expr *unstable.Node) (*docNode, error) {
+	keys, line := tomlKey(p, expr)
+	parent, err := tomlTable(root, keys[:len(keys)-1], line)
+	if err != nil {
+		return nil, err
+	}
+	key := keys[len(keys)-1]
+	existing, ok := parent.fields[key]
+	if expr.Kind == unstable.Table {
+		if ok && existing.kind == docTable && existing.implicit {
+			// The table was created by an earlier header like `[a.b]`.
+			existing.implicit = false
+			return existing, nil
+		}
+		table := newDocTable(line)
+		return table, parent.set(key, table)
+	}
+	if !ok {
+		existing = &docNode{line: line, kind: docArray}
+		if err := parent.set(key, existing); err != nil {
+			return nil, err
+		}
+	} else if existing.kind != docArray {
+		return nil, fmt.Errorf("line %d: %q is already defined as a %s on line %d",
+			line, key, existing.kind, existing.line)
+	}
+	table := newDocTable(line)
+	existing.items = append(existing.items, table)
+	return table, nil
+}
+
+func parseTOMLKeyValue(p *unstable.Parser, table *docNode, expr *unstable.Node) error {
+	keys, line := tomlKey(p, expr)
+	parent, err := tomlTable(table, keys[:len(keys)-1], line)
+	if err != nil {
+		return err
+	}
+	value, err := parseTOMLValue(p, expr.Value(), line)
+	if err != nil {
+		return err
+	}
+	return parent.set(keys[len(keys)-1], value)
+}
+
+func parseTOMLValue(p *unstable.Parser, node *unstable.Node, fallback int) (*docNode, error) {
+	line := tomlLine(p, node, fallback)
+	switch node.Kind {
+	case unstable.String:
+		return &docNode{line: line, kind: docString, str: string(node.Data)}, nil
+	case unstable.Bool:
+		return &docNode{line: line, kind: docBool, str: string(node.Data)}, nil
+	case unstable.Array:
+		array := &docNode{line: line, kind: docArray}
+		for it := node.Children(); it.Next(); {
+			if it.Node().Kind == unstable.Comment {
+				continue
+			}
+			item, err := parseTOMLValue(p, it.Node(), line)
+			if err != nil {
+				return nil, err
+			}
+			array.items = append(array.items, item)
+		}
+		return array, nil
+	case unstable.InlineTable:
+		table := newDocTable(line)
+		for it := node.Children(); it.Next(); {
+			if it.Node().Kind != unstable.KeyValue {
+				continue
+			}
+			if err := parseTOMLKeyValue(p, table, it.Node()); err != nil {
+				return nil, err
+			}
+		}
+		return table, nil
+	default:
+		return nil, fmt.Errorf("line %d: unsupported value %q (expected a string, boolean, array, or table)", line, node.Data)
+	}
+}
+
+// parseJSONDoc parses a JSON rules file.  Numbers and nulls are rejected, as
+// rules files have no use for them.
+func parseJSONDoc(data []byte) (*docNode, error) {
+	p := jsonParser{data: data, dec: json.NewDecoder(bytes.NewReader(data))}
+	root, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	if root.kind != docTable {
+		return nil, fmt.Errorf("line %d: expected a JSON object at the top level", root.line)
+	}
+	if _, err := p.dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("line %d: unexpected data after top-level object", p.line())
+	}
+	return root, nil
+}
+
+type jsonParser struct {
+	data []byte
+	dec  *json.Decoder
+}
+
+// line returns the line on which the most recently read token ends.
+func (p *jsonParser) line() int {
+	return 1 + bytes.Count(p.data[:p.dec.InputOffset()], []byte("\n"))
+}
+
+func (p *jsonParser) token() (json.Token, error) {
+	token, err := p.dec.Token()
+	if err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			line := 1 + bytes.Count(p.data[:syntaxErr.Offset], []byte("\n"))
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		return nil, fmt.Errorf("line %d: %w", p.line(), err)
+	}
+	return token, nil
+}
+
+func (p *jsonParser) parseValue() (*docNode, error) {
+	token, err := p.token()
+	if err != nil {
+		return nil, err
+	}
+	line := p.line()
+	switch t := token.(type) {
+	case string:
+		return &docNode{line: line, kind: docString, str: t}, nil
+	case bool:
+		return &docNode{line: line, kind: docBool, str: strconv.FormatBool(t)}, nil
+	case json.Delim:
+		switch t {
+		case '{':
+			return p.parseObject(line)
+		case '[':
+			return p.parseArray(line)
+		}
+	}
+	return nil, fmt.Errorf("line %d: unsupported value %v (expected a string, boolean, array, or object)", line, token)
+}
+
+func (p *jsonParser) parseObject(line int) (*docNode, error) {
+	table := newDocTable(line)
+	for p.dec.More() {
+		token, err := p.token()
+		if err != nil {
+			return nil, err
+		}
+		key := token.(string)
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		if err := table.set(key, value); err != nil {
+			return nil, err
+		}
+	}
+	_, err := p.token()
+	return table, err
+}
+
+func (p *jsonParser) parseArray(line int) (*docNode, error) {
+	array := &docNode{line: line, kind: docArray}
+	for p.dec.More() {
+		item, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		array.items = append(array.items, item)
+	}
+	_, err := p.token()
+	return array, err
+}
@@ -0,0 +1,524 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// RulesFile holds user-supplied mappings that are loaded from a TOML or JSON
+// file (see LoadRulesFile).  Example in TOML:
+//
+//	# "extend" (default) applies the mappings on top of the built-in tables,
+//	# "replace" applies them instead of the built-in tables.
+//	mode = "extend"
+//	delete = ["\u200B"]
+//
+//	[strings]
+//	"Œuvre" = "Oeuvre"
+//
+//	[runes]
+//	"ø" = "o"
+//
+//	[categories]
+//	So = "_"
+//
+//	[[regex]]
+//	pattern = '^Track (\d+) - (.+) - (.+)$'
+//	replace = "${1}_${2}_${3}"
+//	scope = "files"
+//	extensions = ["mp3", "flac"]
+//	before = "replace-umlauts"
+type RulesFile struct {
+	// Replace is true if the mappings replace the built-in tables rather than
+	// extending them.
+	Replace bool
+	// Strings maps strings to replacement strings.
+	Strings []Mapping
+	// Runes maps runes to replacement runes.
+	Runes []Mapping
+	// Delete lists runes that are removed.
+	Delete []Mapping
+	// Categories maps Unicode categories (like "So") to replacement strings.
+	Categories []Mapping
+	// Regexes lists rules that rewrite names with regular expressions.
+	Regexes []RegexMapping
+}
+
+// Mapping is a single entry of a rules file.
+type Mapping struct {
+	From string
+	To   string
+	// Line is the line in the rules file on which the mapping is defined.
+	Line int
+}
+
+// RegexMapping is a `[[regex]]` entry of a rules file, which replaces all
+// matches of Pattern with Replace, as `regexp.Regexp.ReplaceAllString` does.
+// Patterns match the names of directories and the names of files without
+// their extensions, which are kept intact.
+type RegexMapping struct {
+	// Name is the name of the rule, e.g. for --disable-rule.  It defaults to
+	// "user-regex-1" etc. in the order of the entries.
+	Name    string
+	Pattern *regexp.Regexp
+	Replace string
+	// Scope is "all" (default), "files", or "directories".
+	Scope string
+	// Extensions limits the rule to files with these extensions, like
+	// ".mp3", in lowercase.  If empty, the rule applies to all files.
+	Extensions []string
+	// Before and After name the rule that the rule runs before or after.  If
+	// both are empty, the rule runs before the other rules, like all
+	// mappings of a rules file (see Combine).
+	Before string
+	After  string
+	// Line is the line in the rules file on which the entry is defined.
+	Line int
+}
+
+// RegexScopes lists the scopes of `[[regex]]` entries (see RegexMapping).
+var RegexScopes = []string{"all", "files", "directories"}
+
+// LoadRulesFile reads and validates a rules file.  The file format is derived
+// from the file extension, which must be `.toml` or `.json`.
+func LoadRulesFile(path string) (*RulesFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc *docNode
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".toml":
+		doc, err = parseTOMLDoc(data)
+	case ".json":
+		doc, err = parseJSONDoc(data)
+	default:
+		return nil, fmt.Errorf("%s: unsupported rules file format '%s' (must be .toml or .json)", path, ext)
+	}
+	if err == nil {
+		var f *RulesFile
+		if f, err = newRulesFile(doc); err == nil {
+			return f, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: %w", path, err)
+}
+
+func newRulesFile(doc *docNode) (*RulesFile, error) {
+	f := &RulesFile{}
+	for _, key := range doc.keys {
+		node := doc.fields[key]
+		var err error
+		switch key {
+		case "mode":
+			err = f.parseMode(node)
+		case "strings":
+			f.Strings, err = parseMappings(node, key, false)
+		case "runes":
+			f.Runes, err = parseMappings(node, key, true)
+		case "delete":
+			f.Delete, err = parseDeletions(node)
+		case "categories":
+			f.Categories, err = parseCategories(node)
+		case "regex":
+			f.Regexes, err = parseRegexes(node)
+		default:
+			err = fmt.Errorf("line %d: unknown key %q", node.line, key)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := f.validate(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *RulesFile) parseMode(node *docNode) error {
+	if node.kind == docString {
+		switch node.str {
+		case "extend":
+			return nil
+		case "replace":
+			f.Replace = true
+			return nil
+		}
+	}
+	return fmt.Errorf("line %d: mode must be \"extend\" or \"replace\"", node.line)
+}
+
+func expectKind(node *docNode, kind docKind, what string) error {
+	if node.kind != kind {
+		return fmt.Errorf("line %d: %s must be a %s, not a %s", node.line, what, kind, node.kind)
+	}
+	return nil
+}
+
+func parseMappings(node *docNode, section string, runesOnly bool) ([]Mapping, error) {
+	if err := expectKind(node, docTable, section); err != nil {
+		return nil, err
+	}
+	var mappings []Mapping
+	for _, key := range node.keys {
+		value := node.fields[key]
+		if err := expectKind(value, docString, fmt.Sprintf("[%s] %q", section, key)); err != nil {
+			return nil, err
+		}
+		if key == "" {
+			return nil, fmt.Errorf("line %d: [%s] keys must not be empty", value.line, section)
+		}
+		if runesOnly && (utf8.RuneCountInString(key) != 1 || utf8.RuneCountInString(value.str) != 1) {
+			return nil, fmt.Errorf("line %d: [%s] must map a single rune to a single rune, got %q = %q",
+				value.line, section, key, value.str)
+		}
+		mappings = append(mappings, Mapping{From: key, To: value.str, Line: value.line})
+	}
+	return mappings, nil
+}
+
+func parseDeletions(node *docNode) ([]Mapping, error) {
+	if err := expectKind(node, docArray, "delete"); err != nil {
+		return nil, err
+	}
+	var mappings []Mapping
+	for _, item := range node.items {
+		if item.kind != docString || utf8.RuneCountInString(item.str) != 1 {
+			return nil, fmt.Errorf("line %d: delete entries must be single runes", item.line)
+		}
+		mappings = append(mappings, Mapping{From: item.str, Line: item.line})
+	}
+	return mappings, nil
+}
+
+func parseCategories(node *docNode) ([]Mapping, error) {
+	mappings, err := parseMappings(node, "categories", false)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range mappings {
+		if _, ok := unicode.Categories[m.From]; !ok {
+			return nil, fmt.Errorf("line %d: unknown Unicode category %q", m.Line, m.From)
+		}
+	}
+	return mappings, nil
+}
+
+func parseRegexes(node *docNode) ([]RegexMapping, error) {
+	if err := expectKind(node, docArray, "regex"); err != nil {
+		return nil, err
+	}
+	var regexes []RegexMapping
+	names := map[string]int{}
+	for i, item := range node.items {
+		if err := expectKind(item, docTable, "[[regex]]"); err != nil {
+			return nil, err
+		}
+		regex, err := parseRegex(item, i+1)
+		if err != nil {
+			return nil, err
+		}
+		if line, ok := names[regex.Name]; ok {
+			return nil, fmt.Errorf("line %d: duplicate rule name %q (first defined on line %d)", regex.Line, regex.Name, line)
+		}
+		names[regex.Name] = regex.Line
+		regexes = append(regexes, regex)
+	}
+	return regexes, nil
+}
+
+func parseRegex(table *docNode, index int) (RegexMapping, error) {
+	regex := RegexMapping{Name: fmt.Sprintf("user-regex-%d", index), Scope: "all", Line: table.line}
+	var pattern string
+	for _, key := range table.keys {
+		value := table.fields[key]
+		if key == "extensions" {
+			if err := expectKind(value, docArray, "[[regex]] extensions"); err != nil {
+				return regex, err
+			}
+			for _, item := range value.items {
+				if err := expectKind(item, docString, "[[regex]] extensions"); err != nil {
+					return regex, err
+				}
+				extension := strings.ToLower(item.str)
+				if !strings.HasPrefix(extension, ".") {
+					extension = "." + extension
+				}
+				regex.Extensions = append(regex.Extensions, extension)
+			}
+			continue
+		}
+		if err := expectKind(value, docString, "[[regex]] "+key); err != nil {
+			return regex, err
+		}
+		switch key {
+		case "name":
+			if value.str == "" {
+				return regex, fmt.Errorf("line %d: [[regex]] name must not be empty", value.line)
+			}
+			regex.Name = value.str
+		case "pattern":
+			pattern = value.str
+		case "replace":
+			regex.Replace = value.str
+		case "scope":
+			if !slices.Contains(RegexScopes, value.str) {
+				return regex, fmt.Errorf("line %d: unknown scope %q (must be one of: %s)", value.line, value.str, strings.Join(RegexScopes, ", "))
+			}
+			regex.Scope = value.str
+		case "before":
+			regex.Before = value.str
+		case "after":
+			regex.After = value.str
+		default:
+			return regex, fmt.Errorf("line %d: unknown key %q in [[regex]]", value.line, key)
+		}
+	}
+	if _, ok := table.fields["pattern"]; !ok {
+		return regex, fmt.Errorf("line %d: [[regex]] needs a pattern", table.line)
+	}
+	if _, ok := table.fields["replace"]; !ok {
+		return regex, fmt.Errorf("line %d: [[regex]] needs a replacement (replace = \"\" removes the matches)", table.line)
+	}
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return regex, fmt.Errorf("line %d: invalid pattern %q: %s", table.fields["pattern"].line, pattern, err.Error())
+	}
+	regex.Pattern = compiled
+	if err := checkTemplate(compiled, regex.Replace); err != nil {
+		return regex, fmt.Errorf("line %d: %s", table.fields["replace"].line, err.Error())
+	}
+	if len(regex.Extensions) > 0 && regex.Scope == "directories" {
+		return regex, fmt.Errorf("line %d: [[regex]] extensions only apply to files, not to scope \"directories\"", table.fields["extensions"].line)
+	}
+	if regex.Before != "" && regex.After != "" {
+		return regex, fmt.Errorf("line %d: [[regex]] must not set both before and after", table.line)
+	}
+	return regex, nil
+}
+
+// checkTemplate reports references like `$3` or `${name}` in the replacement
+// to groups that the pattern does not have, which would silently expand to
+// nothing.
+func checkTemplate(pattern *regexp.Regexp, template string) error {
+	for _, match := range templateRef.FindAllStringSubmatch(template, -1) {
+		ref := match[1] + match[2]
+		if ref == "" {
+			continue // `$$` is a literal `$`
+		}
+		if n, err := strconv.Atoi(ref); err == nil {
+			if n > pattern.NumSubexp() {
+				return fmt.Errorf("replacement refers to group $%d, but the pattern has %d groups", n, pattern.NumSubexp())
+			}
+		} else if pattern.SubexpIndex(ref) < 0 {
+			return fmt.Errorf("replacement refers to group %q, which the pattern does not have", ref)
+		}
+	}
+	return nil
+}
+
+// templateRef matches the references to groups in a replacement, like
+// `$1`, `${1}`, and `${name}`, and the escaped dollar sign `$$`.
+var templateRef = regexp.MustCompile(`\$(?:\$|\{(\w+)\}|(\w+))`)
+
+// validate reports mappings that contradict each other or that form cycles,
+// such as `"a" = "b"` together with `"b" = "a"`.
+func (f *RulesFile) validate() error {
+	defined := map[string]Mapping{}
+	for _, m := range append(append([]Mapping(nil), f.Runes...), f.Delete...) {
+		if other, ok := defined[m.From]; ok {
+			return fmt.Errorf("line %d: conflicting mappings for %q (also mapped on line %d)",
+				m.Line, m.From, other.Line)
+		}
+		defined[m.From] = m
+	}
+	for _, m := range f.Strings {
+		if other, ok := defined[m.From]; ok && other.To != m.To {
+			return fmt.Errorf("line %d: conflicting mappings for %q (also mapped on line %d)",
+				m.Line, m.From, other.Line)
+		}
+	}
+	if err := checkCycles(f.Runes); err != nil {
+		return err
+	}
+	return checkCycles(f.Strings)
+}
+
+// checkCycles reports mappings that map back to where they started, like
+// "a" = "b" and "b" = "a".  Self-mappings like "a" = "a" are no-ops rather
+// than cycles.
+func checkCycles(mappings []Mapping) error {
+	byFrom := map[string]Mapping{}
+	for _, m := range mappings {
+		if m.From != m.To {
+			byFrom[m.From] = m
+		}
+	}
+	for _, start := range mappings {
+		if start.From == start.To {
+			continue
+		}
+		var chain []string
+		lines := map[int]bool{}
+		for m, ok := start, true; ok; m, ok = byFrom[m.To] {
+			chain = append(chain, fmt.Sprintf("%q", m.From))
+			lines[m.Line] = true
+			if m.To == start.From {
+				chain = append(chain, fmt.Sprintf("%q", start.From))
+				return fmt.Errorf("line %d: mappings form a cycle %s (see lines %s)",
+					start.Line, strings.Join(chain, " -> "), joinLines(lines))
+			}
+			if len(chain) > len(mappings) {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func joinLines(lines map[int]bool) string {
+	var sorted []int
+	for line := range lines {
+		sorted = append(sorted, line)
+	}
+	sort.Ints(sorted)
+	var parts []string
+	for _, line := range sorted {
+		parts = append(parts, fmt.Sprint(line))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Rules returns the rules defined by the rules file.
+func (f *RulesFile) Rules() []Rule {
+	var rules []Rule
+	var oldnew []string
+	for _, m := range f.Strings {
+		// A self-mapping is a no-op, but would keep longer mappings that
+		// start at the same position from matching.
+		if m.From != m.To {
+			oldnew = append(oldnew, m.From, m.To)
+		}
+	}
+	if len(oldnew) > 0 {
+		rules = append(rules, NewReplaceRule("user-strings", oldnew...))
+	}
+	if len(f.Runes) > 0 || len(f.Delete) > 0 {
+		runes := map[rune]rune{}
+		for _, m := range f.Runes {
+			from, _ := utf8.DecodeRuneInString(m.From)
+			to, _ := utf8.DecodeRuneInString(m.To)
+			runes[from] = to
+		}
+		for _, m := range f.Delete {
+			from, _ := utf8.DecodeRuneInString(m.From)
+			runes[from] = -1
+		}
+		rules = append(rules, NewMapRule("user-runes", func(r rune) rune {
+			if mapped, ok := runes[r]; ok {
+				return mapped
+			}
+			return r
+		}))
+	}
+	for _, m := range f.Categories {
+		rules = append(rules, NewCategoryRule("user-category-"+m.From, unicode.Categories[m.From], m.To))
+	}
+	for _, regex := range f.Regexes {
+		if regex.Before == "" && regex.After == "" {
+			rules = append(rules, newUserRegexRule(regex))
+		}
+	}
+	return rules
+}
+
+// Combine returns the rules of the rules file followed by the given rules.
+// The user-supplied rules thus take precedence over the given ones.  In
+// replace mode, the given rules are stripped of the built-in mapping tables
+// (see builtinTables).  Regex rules with a position run right before or
+// after the rule of that name, which must be one of the combined rules.  The
+// names of regex rules must not be the names of any other combined rule.
+func (f *RulesFile) Combine(rules []Rule) ([]Rule, error) {
+	combined := f.Rules()
+	for _, rule := range rules {
+		if !f.Replace || !builtinTables[rule.Name()] {
+			combined = append(combined, rule)
+		}
+	}
+	for _, regex := range f.Regexes {
+		for _, rule := range combined {
+			if _, ok := rule.(userRegexRule); !ok && rule.Name() == regex.Name {
+				return nil, fmt.Errorf("line %d: [[regex]] name %q is the name of another rule (see --list-rules)", regex.Line, regex.Name)
+			}
+		}
+	}
+	for _, regex := range f.Regexes {
+		anchor, offset := regex.Before, 0
+		if regex.After != "" {
+			anchor, offset = regex.After, 1
+		}
+		if anchor == "" {
+			continue
+		}
+		i := slices.IndexFunc(combined, func(rule Rule) bool { return rule.Name() == anchor })
+		if i < 0 {
+			return nil, fmt.Errorf("line %d: [[regex]] %q refers to unknown rule '%s' (see --list-rules)", regex.Line, regex.Name, anchor)
+		}
+		if offset == 1 {
+			// Keep rules that run after the same rule in file order.
+			for i+1 < len(combined) && isAfter(f.Regexes, combined[i+1].Name(), anchor) {
+				i++
+			}
+		}
+		combined = slices.Insert(combined, i+offset, newUserRegexRule(regex))
+	}
+	return combined, nil
+}
+
+// isAfter reports whether name is the name of a regex rule that runs after
+// the rule anchor.
+func isAfter(regexes []RegexMapping, name, anchor string) bool {
+	return slices.ContainsFunc(regexes, func(regex RegexMapping) bool {
+		return regex.Name == name && regex.After == anchor
+	})
+}
+
+type userRegexRule struct {
+	regexRule
+	files, directories bool
+	extensions         []string
+}
+
+func newUserRegexRule(regex RegexMapping) Rule {
+	return userRegexRule{
+		regexRule:   regexRule{name: regex.Name, pattern: regex.Pattern, replacement: regex.Replace},
+		files:       regex.Scope != "directories",
+		directories: regex.Scope != "files" && len(regex.Extensions) == 0,
+		extensions:  regex.Extensions,
+	}
+}
+
+func (r userRegexRule) Apply(name string) string {
+	return r.ApplyEntry(name, false)
+}
+
+// ApplyEntry applies the rule to the name without its extension, if the
+// rule's scope includes the entry.
+func (r userRegexRule) ApplyEntry(name string, isDir bool) string {
+	if isDir && !r.directories || !isDir && !r.files {
+		return name
+	}
+	stem, extension := splitExtension(name, isDir)
+	if len(r.extensions) > 0 && !slices.Contains(r.extensions, strings.ToLower(extension)) {
+		return name
+	}
+	return r.regexRule.Apply(stem) + extension
+}
@@ -0,0 +1,260 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeRulesFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadRulesFile(t *testing.T) {
+	firstLines := map[string]int{"../../test/rules/extend.toml": 6, "../../test/rules/extend.json": 5}
+	for path, line := range firstLines {
+		f, err := LoadRulesFile(path)
+		if !assert.NoError(t, err, path) {
+			continue
+		}
+		assert.False(t, f.Replace)
+		assert.Equal(t, []Mapping{{From: "Œ", To: "OE", Line: line}, {From: "œ", To: "oe", Line: line + 1}}, f.Strings)
+		assert.Len(t, f.Runes, 2)
+		assert.Len(t, f.Delete, 2)
+		assert.Equal(t, "So", f.Categories[0].From)
+
+		rules, err := f.Combine(DefaultRules())
+		assert.NoError(t, err)
+		s := NewSanitizer(rules...)
+		assert.Equal(t, "OEuvre Kobenhavn.txt", s.Sanitize("Œuvre​ København®.txt"))
+		assert.Equal(t, "Ae_.txt", s.Sanitize("Ä☂.txt"), "built-in tables still apply")
+	}
+}
+
+func TestRulesFileReplaceMode(t *testing.T) {
+	path := writeRulesFile(t, "rules.toml", `
+mode = "replace"
+[strings]
+"ä" = "a"
+`)
+	f, err := LoadRulesFile(path)
+	assert.NoError(t, err)
+	assert.True(t, f.Replace)
+	rules, err := f.Combine(DefaultRules())
+	assert.NoError(t, err)
+	s := NewSanitizer(rules...)
+	assert.Equal(t, "aO!", s.Sanitize("äÖ!"), "built-in tables are replaced")
+	for _, rule := range s.Rules() {
+		assert.False(t, builtinTables[rule.Name()], rule.Name())
+	}
+}
+
+func TestRulesFileSelfMappingsAreNoOps(t *testing.T) {
+	path := writeRulesFile(t, "rules.toml", `
+[strings]
+"a" = "a"
+"ab" = "x"
+[runes]
+"b" = "b"
+`)
+	f, err := LoadRulesFile(path)
+	assert.NoError(t, err)
+	rules, err := f.Combine(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "x-b", NewSanitizer(rules...).Sanitize("ab-b"))
+}
+
+func TestRulesFileRegexRules(t *testing.T) {
+	f, err := LoadRulesFile("../../test/rules/regex.toml")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, f.Regexes, 3)
+	assert.Equal(t, []string{".mp3", ".flac"}, f.Regexes[0].Extensions)
+	assert.Equal(t, "user-regex-3", f.Regexes[2].Name)
+	assert.Equal(t, 19, f.Regexes[2].Line)
+
+	rules, err := f.Combine(DefaultRules())
+	assert.NoError(t, err)
+	s := NewSanitizer(rules...)
+	var names []string
+	for _, rule := range s.Rules() {
+		names = append(names, rule.Name())
+	}
+	assert.Equal(t, []string{"shorten-tracks", "remove-copy-markers", "repair-mojibake"}, names[:3])
+	assert.Equal(t, []string{"trim-dots-and-spaces", "user-regex-3", "rewrite-windows-reserved-names"}, names[len(names)-3:])
+
+	assert.Equal(t, "01_Die Aerzte_Maenner sind Schweine.mp3",
+		s.SanitizeEntry("Track 01 - Die Ärzte - Männer sind Schweine.mp3", false))
+	assert.Equal(t, "Track 01 - Intro - Live.wav", s.SanitizeEntry("Track 01 - Intro - Live.wav", false), "other extensions are left alone")
+	assert.Equal(t, "Track 01 - Intro - Live", s.SanitizeEntry("Track 01 - Intro - Live", true), "directories are left alone")
+	assert.Equal(t, "Report.pdf", s.SanitizeEntry("Report (1).pdf", false))
+	assert.Equal(t, "Album (1)", s.SanitizeEntry("Album (1)", true))
+	assert.Equal(t, "2024_05_01 Urlaub", s.SanitizeEntry("2024-05-01 Urlaub", true))
+	assert.Equal(t, "2024-05-01 Urlaub.jpg", s.SanitizeEntry("2024-05-01 Urlaub.jpg", false))
+}
+
+func TestRulesFileRegexRulePositions(t *testing.T) {
+	path := writeRulesFile(t, "rules.json", `{
+  "regex": [
+    {"name": "a", "pattern": "x", "replace": "y", "after": "replace-umlauts"},
+    {"name": "b", "pattern": "x", "replace": "y", "after": "replace-umlauts"},
+    {"name": "c", "pattern": "x", "replace": "y", "before": "replace-umlauts"},
+    {"name": "d", "pattern": "x", "replace": "y", "before": "replace-umlauts"}
+  ]
+}`)
+	f, err := LoadRulesFile(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	rules, err := f.Combine([]Rule{replaceSymbols, replaceUmlauts, collapsePunctuation})
+	assert.NoError(t, err)
+	var names []string
+	for _, rule := range rules {
+		names = append(names, rule.Name())
+	}
+	assert.Equal(t, []string{"replace-symbols", "c", "d", "replace-umlauts", "a", "b", "collapse-punctuation"}, names)
+
+	_, err = f.Combine([]Rule{replaceSymbols})
+	assert.EqualError(t, err, `line 3: [[regex]] "a" refers to unknown rule 'replace-umlauts' (see --list-rules)`)
+}
+
+func TestRulesFileRegexRuleNamesMustBeUnique(t *testing.T) {
+	path := writeRulesFile(t, "rules.toml", `[strings]
+"&" = " and "
+
+[[regex]]
+name = "trim-dots-and-spaces"
+pattern = 'x'
+replace = "y"
+
+[[regex]]
+name = "user-strings"
+pattern = 'x'
+replace = "y"
+`)
+	f, err := LoadRulesFile(path)
+	require.NoError(t, err)
+	_, err = f.Combine(DefaultRules())
+	assert.EqualError(t, err, `line 4: [[regex]] name "trim-dots-and-spaces" is the name of another rule (see --list-rules)`)
+	_, err = f.Combine(nil)
+	assert.EqualError(t, err, `line 9: [[regex]] name "user-strings" is the name of another rule (see --list-rules)`)
+}
+
+func TestRulesFileErrorsReportLineNumbers(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"rules.toml", "[runes]\n\"a\" = \"b\"\n\"b\" = \"a\"\n",
+			`line 2: mappings form a cycle "a" -> "b" -> "a" (see lines 2, 3)`},
+		{"rules.toml", "[strings]\n\"x\" = \"y\"\n\"y\" = \"zz\"\n\"zz\" = \"x\"\n",
+			`line 2: mappings form a cycle "x" -> "y" -> "zz" -> "x" (see lines 2, 3, 4)`},
+		{"rules.toml", "delete = [\n  \"a\",\n]\n[runes]\n\"a\" = \"b\"\n",
+			`line 2: conflicting mappings for "a" (also mapped on line 5)`},
+		{"rules.toml", "[runes]\n\"a\" = \"b\"\n[strings]\n\"a\" = \"c\"\n",
+			`line 4: conflicting mappings for "a" (also mapped on line 2)`},
+		{"rules.toml", "[runes]\n\"a\" = \"b\"\n\"a\" = \"c\"\n",
+			`line 3: duplicate key "a" (first defined on line 2)`},
+		{"rules.toml", "[runes]\n\"a\" = \"bc\"\n",
+			`line 2: [runes] must map a single rune to a single rune, got "a" = "bc"`},
+		{"rules.toml", "\n[categories]\nXx = \"_\"\n",
+			`line 3: unknown Unicode category "Xx"`},
+		{"rules.toml", "mode = \"merge\"\n",
+			`line 1: mode must be "extend" or "replace"`},
+		{"rules.toml", "[strings]\n\"a\" = \"b\n",
+			`line 2: basic strings cannot have new lines`},
+		{"rules.toml", "[runes]\n\"a\" = 1\n",
+			`line 2: unsupported value "1" (expected a string, boolean, array, or table)`},
+		{"rules.toml", "strings = \"x\"\n[strings.a]\n",
+			`line 2: "strings" is already defined as a string on line 1`},
+		{"rules.toml", "unknown = true\n",
+			`line 1: unknown key "unknown"`},
+		{"rules.json", "{\n  \"runes\": {\n    \"a\": \"b\",\n    \"b\": \"a\"\n  }\n}\n",
+			`line 3: mappings form a cycle "a" -> "b" -> "a" (see lines 3, 4)`},
+		{"rules.json", "{\n  \"strings\": {\n    \"a\": \"b\",\n    \"a\": \"c\"\n  }\n}\n",
+			`line 4: duplicate key "a" (first defined on line 3)`},
+		{"rules.json", "{\n  \"runes\": {\n    \"a\": 1\n  }\n}\n",
+			`line 3: unsupported value 1`},
+		{"rules.toml", "[[regex]]\nreplace = \"\"\n",
+			`line 1: [[regex]] needs a pattern`},
+		{"rules.toml", "[[regex]]\npattern = \"x\"\n",
+			`line 1: [[regex]] needs a replacement (replace = "" removes the matches)`},
+		{"rules.toml", "[[regex]]\npattern = \"(x\"\nreplace = \"\"\n",
+			"line 2: invalid pattern \"(x\": error parsing regexp: missing closing ): `(x`"},
+		{"rules.toml", "[[regex]]\npattern = \"(x)\"\n\nreplace = \"$2\"\n",
+			`line 4: replacement refers to group $2, but the pattern has 1 groups`},
+		{"rules.toml", "[[regex]]\npattern = \"(?P<year>x)\"\nreplace = \"${month}\"\n",
+			`line 3: replacement refers to group "month", which the pattern does not have`},
+		{"rules.toml", "[[regex]]\npattern = \"x\"\nreplace = \"\"\nscope = \"links\"\n",
+			`line 4: unknown scope "links" (must be one of: all, files, directories)`},
+		{"rules.toml", "[[regex]]\npattern = \"x\"\nreplace = \"\"\nscope = \"directories\"\nextensions = [\"mp3\"]\n",
+			`line 5: [[regex]] extensions only apply to files, not to scope "directories"`},
+		{"rules.toml", "[[regex]]\npattern = \"x\"\nreplace = \"\"\nbefore = \"a\"\nafter = \"b\"\n",
+			`line 1: [[regex]] must not set both before and after`},
+		{"rules.toml", "[[regex]]\npattern = \"x\"\nreplace = \"\"\nflags = \"i\"\n",
+			`line 4: unknown key "flags" in [[regex]]`},
+		{"rules.toml", "[[regex]]\nname = \"x\"\npattern = \"x\"\nreplace = \"\"\n[[regex]]\nname = \"x\"\npattern = \"y\"\nreplace = \"\"\n",
+			`line 5: duplicate rule name "x" (first defined on line 1)`},
+		{"rules.yaml", "", `unsupported rules file format '.yaml'`},
+	}
+	for _, test := range tests {
+		path := writeRulesFile(t, test.name, test.content)
+		_, err := LoadRulesFile(path)
+		if assert.Error(t, err, test.content) {
+			assert.Contains(t, err.Error(), path+": "+test.expected)
+		}
+	}
+}
+
+func TestParseTOMLDoc(t *testing.T) {
+	doc, err := parseTOMLDoc([]byte(`
+# comment
+key = 'literal \u'  # trailing comment
+list = ["a", "ä", "\U0001F600",
+  "b", # comment inside array
+]
+flag = true
+
+[[items]]
+name = "one"
+
+[[items]]
+name = "two"
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"key", "list", "flag", "items"}, doc.keys)
+	assert.Equal(t, `literal \u`, doc.fields["key"].str)
+	list := doc.fields["list"]
+	assert.Equal(t, 4, list.line)
+	assert.Len(t, list.items, 4)
+	assert.Equal(t, "ä", list.items[1].str)
+	assert.Equal(t, "😀", list.items[2].str)
+	assert.Equal(t, 5, list.items[3].line)
+	assert.Equal(t, docBool, doc.fields["flag"].kind)
+	items := doc.fields["items"]
+	assert.Len(t, items.items, 2)
+	assert.Equal(t, "two", items.items[1].fields["name"].str)
+	assert.Equal(t, 13, items.items[1].fields["name"].line)
+
+	doc, err = parseTOMLDoc([]byte(`
+strings."a" = "b"
+runes = { "c" = "d" }
+[x.y]
+[x]
+z = false
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, "b", doc.fields["strings"].fields["a"].str)
+	assert.Equal(t, 3, doc.fields["runes"].fields["c"].line)
+	assert.Equal(t, []string{"y", "z"}, doc.fields["x"].keys)
+	assert.Equal(t, 6, doc.fields["x"].fields["z"].line)
+}
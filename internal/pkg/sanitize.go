@@ -1,49 +1,64 @@
 package internal
 
 import (
-	"regexp"
-	"strings"
 	"unicode"
 
-	"golang.org/x/text/runes"
-	"golang.org/x/text/transform"
 	"golang.org/x/text/unicode/norm"
 )
 
+// Sanitize sanitizes the name with the default rules (see DefaultRules).
 func Sanitize(filename string) string {
-	s := replacer.Replace(filename)
-	s = removeAccents(s)
-	s = replaceInvisibleCharsWithHyphen(s)
-	s = replacePrivateUseCharsWithHyphen(s)
-	return s
+	return defaultSanitizer.Sanitize(filename)
 }
 
-var replacer = strings.NewReplacer(
-	"ß", "ss",
-	"Ä", "Ae",
-	"Ö", "Oe",
-	"Ü", "Ue",
-	"ä", "ae",
-	"ö", "oe",
-	"ü", "ue",
-	// U+0308 Combining Diaeresis (https://en.wikipedia.org/wiki/Diaeresis_(diacritic)
-	// This character is a Non-spacing Mark and inherits its script property
-	// from the preceding character. The character is also known as
-	// 'double dot above', 'umlaut', 'Greek dialytika', and 'double derivative'.
-	//
-	// Note how the 'Ä' below is actually two chars: an 'A' followed by U+0308,
-	// i.e., the two dots to be added on top of the 'A'. Try it yourself: put
-	// your cursor to the left of the 'Ä', then use the arrow keys on your
-	// keyboard to move the cursor to the right. You will notice that you need
-	// two key presses to get across 'Ä'. (This may not work in all text
-	// editors, such as neovim. It does work in GoLand IDE, for example.)
-	"Ä", "Ae", // A<0308> (see above)
-	"Ö", "Oe", // O<0308> (see above)
-	"Ü", "Ue", // U<0308> (see above)
-	"ä", "ae", // a<0308> (see above)
-	"ö", "oe", // o<0308> (see above)
-	"ü", "ue", // u<0308> (see above)
-	// a few hardcoded rules to make repeated `.` and `,` more pleasant
+var defaultSanitizer = NewSanitizer(DefaultRules()...)
+
+// DefaultRules returns the rules that sauber applies unless configured
+// otherwise, in the order in which they are applied.
+func DefaultRules() []Rule {
+	return []Rule{
+		repairMojibake,
+		replaceSymbols,
+		normalizeCompatibility,
+		foldConfusables,
+		replaceUmlauts,
+		collapsePunctuation,
+		transliterateCyrillic,
+		transliterateGreek,
+		transliterateScripts,
+		NewNormalizeRule("decompose-nfd", norm.NFD),
+		NewCategoryRule("remove-nonspacing-marks", unicode.Mn, ""),
+		transliterateLatin,
+		NewMapRule("map-special-runes", mapSpecialRune),
+		NewNormalizeRule("compose-nfc", norm.NFC),
+		// `\p{Cc}`: C0 and C1 control characters
+		replaceControlChars,
+		// `\p{Cf}`: invisible formatting indicator
+		NewCategoryRule("replace-invisible-chars", unicode.Cf, "-"),
+		// `\p{Co}`: any code point reserved for private use
+		NewCategoryRule("replace-private-use-chars", unicode.Co, "-"),
+		replaceWindowsForbiddenChars,
+		NewTrimRule(""),
+		rewriteWindowsReservedNames,
+	}
+}
+
+// builtinTables names the default rules that consist of hard-coded mapping
+// tables.  A rules file in replace mode supersedes these rules.
+var builtinTables = map[string]bool{
+	"replace-symbols":         true,
+	"normalize-compatibility": true,
+	"replace-umlauts":         true,
+	"collapse-punctuation":    true,
+	"transliterate-cyrillic":  true,
+	"transliterate-greek":     true,
+	"transliterate-scripts":   true,
+	"transliterate-latin":     true,
+	"map-special-runes":       true,
+}
+
+// a few hardcoded rules to make repeated `.` and `,` more pleasant
+var collapsePunctuation = NewReplaceRule("collapse-punctuation",
 	",,,,,", ",",
 	",,,,", ",",
 	",,,", ",",
@@ -53,73 +68,44 @@ var replacer = strings.NewReplacer(
 	"…", "...", // horizontal ellipsis
 )
 
-func removeAccents(s string) string {
-	t := transform.Chain(
-		norm.NFD,
-		runes.Remove(runes.In(unicode.Mn)),
-		runes.Map(func(r rune) rune {
-			switch r {
-			case 'ą':
-				return 'a'
-			case 'ć':
-				return 'c'
-			case 'đ':
-				return 'd'
-			case 'Đ':
-				return 'D'
-			case 'ę':
-				return 'e'
-			case 'ł':
-				return 'l'
-			case 'ń':
-				return 'n'
-			case 'ó':
-				return 'o'
-			case 'ś':
-				return 's'
-			case 'ż':
-				return 'z'
-			case 'ź':
-				return 'z'
-			case '%':
-				return '_'
-			case '?':
-				return '_'
-			case '!':
-				return '_'
-			case '|':
-				return '_'
-			case '$':
-				return '_'
-			case '–': // en dash
-				return '-' // hyphen
-			case '—': // em dash
-				return '-' // hyphen
-			}
-			return r
-		}),
-		norm.NFC)
-	output, _, e := transform.String(t, s)
-	if e != nil {
-		panic(e)
+func mapSpecialRune(r rune) rune {
+	switch r {
+	case 'ą':
+		return 'a'
+	case 'ć':
+		return 'c'
+	case 'đ':
+		return 'd'
+	case 'Đ':
+		return 'D'
+	case 'ę':
+		return 'e'
+	case 'ł':
+		return 'l'
+	case 'ń':
+		return 'n'
+	case 'ó':
+		return 'o'
+	case 'ś':
+		return 's'
+	case 'ż':
+		return 'z'
+	case 'ź':
+		return 'z'
+	case '%':
+		return '_'
+	case '?':
+		return '_'
+	case '!':
+		return '_'
+	case '|':
+		return '_'
+	case '$':
+		return '_'
+	case '–': // en dash
+		return '-' // hyphen
+	case '—': // em dash
+		return '-' // hyphen
 	}
-	return output
-}
-
-func replaceInvisibleCharsWithHyphen(str string) string {
-	// `\p{Cf}`: invisible formatting indicator
-	reg := regexp.MustCompile(`\p{Cf}`)
-	return reg.ReplaceAllString(str, "-")
-}
-
-func replaceControlCharsWithHyphen(filename string) string {
-	// [:cntrl:] matches all control characters
-	reg := regexp.MustCompile("[[:cntrl:]]")
-	return reg.ReplaceAllString(filename, "-")
-}
-
-func replacePrivateUseCharsWithHyphen(str string) string {
-	// `\p{Co}`: any code point reserved for private use
-	reg := regexp.MustCompile(`\p{Co}`)
-	return reg.ReplaceAllString(str, "-")
+	return r
 }
@@ -12,7 +12,8 @@ func TestSanitize(t *testing.T) {
 	assert.Equal(t, ".DS_Store", Sanitize(".DS_Store"))
 	assert.Equal(t, "@eaDir", Sanitize("@eaDir"))
 	assert.Equal(t, "--", Sanitize("–—"), "replace en-dash and em-dash with hyphens")
-	assert.Equal(t, "...", Sanitize("…"), "replace horizontal ellipsis")
+	assert.Equal(t, "_", Sanitize("…"), "names of a horizontal ellipsis only would consist of dots only")
+	assert.Equal(t, "x...y", Sanitize("x…y"), "replace horizontal ellipsis")
 	assert.Equal(t, "x-...- x", Sanitize("x... x"), "replace private use characters with hyphens")
 	// U+200D aka \u200D : zero width joiner (ZWJ)
 	// U+200E aka \u200E : left-to-right mark (LRM)
@@ -28,9 +29,11 @@ func TestSanitize(t *testing.T) {
 	// U+000D aka \x0D : carriage return
 	// U+001B aka \x1B : escape
 	// U+007F aka \x7F : delete
+	// U+0085 aka \u0085 : next line (C1)
+	// U+009B aka \u009B : control sequence introducer (C1)
 	assert.Equal(t,
-		"--------",
-		replaceControlCharsWithHyphen("\x00\x07\x08\x09\x0A\x0D\x1B\x7F"),
+		"----------",
+		Sanitize("\x00\x07\x08\x09\x0A\x0D\x1B\x7F\u0085\u009B"),
 		"replace control characters with hyphens")
 
 	replacedSpecials := "!?%|$"
@@ -38,6 +41,13 @@ func TestSanitize(t *testing.T) {
 		assert.Equal(t, "_", Sanitize(string(c)))
 	}
 
+	windowsForbidden := `<>:"\*`
+	for _, c := range windowsForbidden {
+		assert.Equal(t, "_", Sanitize(string(c)))
+	}
+	assert.Equal(t, "CON_.txt", Sanitize("CON.txt"), "rewrite reserved device names")
+	assert.Equal(t, "lpt1_.mp3", Sanitize("lpt1.mp3"), "rewrite reserved device names")
+
 	keptSpecials := "@#,.-_()[]{}"
 	assert.Equal(t, keptSpecials, Sanitize(keptSpecials), "keep some special characters")
 
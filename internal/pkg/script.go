@@ -0,0 +1,147 @@
+package internal
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ScriptTransliterator transliterates the letters of one script, such as
+// Arabic, into Latin letters.
+type ScriptTransliterator struct {
+	// Script is the name of the script in unicode.Scripts, e.g. "Arabic".
+	Script string
+	// Transliterate transliterates a run of runes of the script.  The run
+	// also contains the combining marks that follow the letters, even if
+	// Unicode assigns them to the Inherited script, as it does for the
+	// Arabic vowel signs.
+	Transliterate func(run []rune) string
+
+	table *unicode.RangeTable
+}
+
+// scriptRegistry holds the registered transliterators by script name.
+var scriptRegistry = map[string]ScriptTransliterator{}
+
+// RegisterScript adds a transliterator to the registry, replacing any
+// transliterator of the same script.  Scripts that have dedicated rules, like
+// Cyrillic and Greek, should not be registered.
+func RegisterScript(t ScriptTransliterator) error {
+	table, ok := unicode.Scripts[t.Script]
+	if !ok {
+		return fmt.Errorf("unknown Unicode script '%s'", t.Script)
+	}
+	t.table = table
+	scriptRegistry[t.Script] = t
+	return nil
+}
+
+func init() {
+	for _, t := range []ScriptTransliterator{
+		{Script: "Arabic", Transliterate: transliterateArabic},
+		{Script: "Devanagari", Transliterate: transliterateDevanagari},
+		{Script: "Hebrew", Transliterate: transliterateHebrew},
+		{Script: "Thai", Transliterate: transliterateThai},
+	} {
+		if err := RegisterScript(t); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// ScriptNames returns the sorted, lowercase names of the registered scripts.
+func ScriptNames() []string {
+	var names []string
+	for name := range scriptRegistry {
+		names = append(names, strings.ToLower(name))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewScriptRule returns a rule that transliterates the given registered
+// scripts, which are named case-insensitively (see ScriptNames).  Letters of
+// other scripts are kept.
+func NewScriptRule(scripts ...string) (Rule, error) {
+	var r scriptRule
+	for _, script := range scripts {
+		found := false
+		for name, t := range scriptRegistry {
+			if strings.EqualFold(name, script) {
+				r.transliterators = append(r.transliterators, t)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown script '%s' (must be one of: %s)", script, strings.Join(ScriptNames(), ", "))
+		}
+	}
+	return r, nil
+}
+
+// transliterateScripts transliterates all registered scripts, including the
+// ones that are registered after the rule was created.
+var transliterateScripts Rule = scriptRule{all: true}
+
+type scriptRule struct {
+	all             bool
+	transliterators []ScriptTransliterator
+}
+
+func (r scriptRule) Name() string { return "transliterate-scripts" }
+
+func (r scriptRule) Apply(name string) string {
+	if !r.all && len(r.transliterators) == 0 {
+		return name
+	}
+	var b strings.Builder
+	runes := []rune(name)
+	for i := 0; i < len(runes); {
+		t, ok := r.transliterator(runes[i])
+		if !ok {
+			b.WriteRune(runes[i])
+			i++
+			continue
+		}
+		n := 1
+		for i+n < len(runes) && (unicode.Is(t.table, runes[i+n]) || unicode.Is(unicode.Inherited, runes[i+n])) {
+			n++
+		}
+		b.WriteString(t.Transliterate(runes[i : i+n]))
+		i += n
+	}
+	return b.String()
+}
+
+func (r scriptRule) transliterator(c rune) (ScriptTransliterator, bool) {
+	if c < unicode.MaxASCII {
+		return ScriptTransliterator{}, false
+	}
+	if r.all {
+		for _, t := range scriptRegistry {
+			if unicode.Is(t.table, c) {
+				return t, true
+			}
+		}
+	}
+	for _, t := range r.transliterators {
+		if unicode.Is(t.table, c) {
+			return t, true
+		}
+	}
+	return ScriptTransliterator{}, false
+}
+
+// transliterateDigit replaces a decimal digit of a non-Latin script with an
+// ASCII digit.
+func transliterateDigit(c rune) (string, bool) {
+	if unicode.IsDigit(c) && c > unicode.MaxASCII {
+		for zero := c; zero >= c-9; zero-- {
+			if unicode.IsDigit(zero) && !unicode.IsDigit(zero-1) {
+				return string('0' + c - zero), true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,64 @@
+package internal
+
+import (
+	"testing"
+	"unicode"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScriptNames(t *testing.T) {
+	assert.Equal(t, []string{"arabic", "devanagari", "hebrew", "thai"}, ScriptNames())
+}
+
+func TestScriptRuleTransliteratesRegisteredScripts(t *testing.T) {
+	assert.Equal(t, "ktab - shalom - namaste - sawatdi.txt", transliterateScripts.Apply("كتاب - שָׁלוֹם - नमस्ते - สวัสดี.txt"))
+	assert.Equal(t, "Café Юрий 東京", transliterateScripts.Apply("Café Юрий 東京"))
+}
+
+func TestScriptRuleOnlyTransliteratesSelectedScripts(t *testing.T) {
+	rule, err := NewScriptRule("Hebrew", "thai")
+	assert.NoError(t, err)
+	assert.Equal(t, "كتاب shalom", rule.Apply("كتاب שָׁלוֹם"))
+
+	rule, err = NewScriptRule()
+	assert.NoError(t, err)
+	assert.Equal(t, "كتاب", rule.Apply("كتاب"))
+}
+
+func TestNewScriptRuleErrors(t *testing.T) {
+	_, err := NewScriptRule("arabic", "klingon")
+	assert.EqualError(t, err, "unknown script 'klingon' (must be one of: arabic, devanagari, hebrew, thai)")
+}
+
+func TestRegisterScript(t *testing.T) {
+	assert.EqualError(t, RegisterScript(ScriptTransliterator{Script: "Klingon"}), "unknown Unicode script 'Klingon'")
+
+	defer delete(scriptRegistry, "Armenian")
+	assert.NoError(t, RegisterScript(ScriptTransliterator{
+		Script: "Armenian",
+		Transliterate: func(run []rune) string {
+			return "hayeren"
+		},
+	}))
+	assert.Contains(t, ScriptNames(), "armenian")
+	rule, err := NewScriptRule("armenian")
+	assert.NoError(t, err)
+	assert.Equal(t, "hayeren.txt", rule.Apply("հայերեն.txt"))
+	assert.Equal(t, "hayeren.txt", transliterateScripts.Apply("հայերեն.txt"), "the default rule sees later registrations")
+}
+
+func TestTransliterateDigit(t *testing.T) {
+	for _, zero := range []rune{'٠', '۰', '०', '๐'} {
+		for i := rune(0); i < 10; i++ {
+			latin, ok := transliterateDigit(zero + i)
+			assert.True(t, ok)
+			assert.Equal(t, string('0'+i), latin)
+		}
+	}
+	_, ok := transliterateDigit('7')
+	assert.False(t, ok)
+	_, ok = transliterateDigit('Ⅶ')
+	assert.False(t, ok)
+	assert.True(t, unicode.IsDigit('๗'))
+}
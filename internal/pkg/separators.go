@@ -0,0 +1,159 @@
+package internal
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// SeparatorPolicy determines how the rule "normalize-separators" rewrites
+// the spaces and separators of names, e.g. to write "My  Song   (Live) .mp3"
+// as "My_Song_(Live).mp3".  The zero value leaves names unchanged.
+type SeparatorPolicy struct {
+	// Spaces replaces each space with the given separator, '_', '-', or '.',
+	// unless it is 0.
+	Spaces rune
+	// Collapse collapses runs of whitespace into a single space, and runs of
+	// the same separator, like "__", into a single separator.  Leading dots
+	// are kept, so that hidden files like "..foo" remain hidden.
+	Collapse bool
+	// Mixed collapses runs of different separators, like "-_-" or " - ", into
+	// a single separator: '-' if the run contains one, else '_', else ' '.
+	// Dots are not part of such runs, so that "Vol. 2" stays as it is.
+	Mixed bool
+	// Extension removes separators before the extension of a file, e.g. in
+	// "Song_ .mp3".
+	Extension bool
+}
+
+// SeparatorPolicies lists the items of the comma-separated policies that
+// ParseSeparatorPolicy accepts.
+var SeparatorPolicies = []string{"collapse", "mixed", "extension", "spaces=_", "spaces=-", "spaces=."}
+
+// ParseSeparatorPolicy parses a comma-separated policy like
+// "collapse,mixed,spaces=_" (see SeparatorPolicies).
+func ParseSeparatorPolicy(s string) (SeparatorPolicy, error) {
+	var p SeparatorPolicy
+	for _, item := range strings.Split(s, ",") {
+		switch strings.TrimSpace(item) {
+		case "collapse":
+			p.Collapse = true
+		case "mixed":
+			p.Mixed = true
+		case "extension":
+			p.Extension = true
+		case "spaces=_":
+			p.Spaces = '_'
+		case "spaces=-":
+			p.Spaces = '-'
+		case "spaces=.":
+			p.Spaces = '.'
+		default:
+			return p, fmt.Errorf("unknown separator policy '%s' (must be one of: %s)", item, strings.Join(SeparatorPolicies, ", "))
+		}
+	}
+	return p, nil
+}
+
+// NewSeparatorRule returns the rule "normalize-separators", which rewrites
+// spaces and separators according to the policy.
+func NewSeparatorRule(policy SeparatorPolicy) Rule {
+	return separatorRule{policy: policy}
+}
+
+type separatorRule struct {
+	policy SeparatorPolicy
+}
+
+func (r separatorRule) Name() string { return "normalize-separators" }
+
+func (r separatorRule) Apply(name string) string {
+	if r.policy.Spaces != 0 {
+		name = strings.ReplaceAll(name, " ", string(r.policy.Spaces))
+	}
+	if r.policy.Collapse {
+		name = collapseSeparators(name)
+	}
+	if r.policy.Mixed {
+		name = collapseMixedSeparators(name)
+	}
+	if r.policy.Extension {
+		name = trimBeforeExtension(name)
+	}
+	return name
+}
+
+func isSeparator(c rune) bool {
+	return c == '_' || c == '-' || c == '.' || unicode.IsSpace(c)
+}
+
+func collapseSeparators(name string) string {
+	var b strings.Builder
+	var last rune
+	for i, c := range name {
+		switch {
+		case unicode.IsSpace(c):
+			c = ' '
+		case c == '.' && strings.TrimLeft(name[:i], ".") == "":
+			// leading dots of hidden files
+			b.WriteRune(c)
+			continue
+		}
+		if c == last && isSeparator(c) {
+			continue
+		}
+		b.WriteRune(c)
+		last = c
+	}
+	return b.String()
+}
+
+func collapseMixedSeparators(name string) string {
+	isMixable := func(c rune) bool { return c == '_' || c == '-' || c == ' ' }
+	var b strings.Builder
+	runes := []rune(name)
+	for i := 0; i < len(runes); {
+		if !isMixable(runes[i]) {
+			b.WriteRune(runes[i])
+			i++
+			continue
+		}
+		j := i
+		for j < len(runes) && isMixable(runes[j]) {
+			j++
+		}
+		run := string(runes[i:j])
+		switch {
+		case len(run) == 1 || strings.Count(run, run[:1]) == len(run):
+			// a single separator, or a run of the same separator
+			b.WriteString(run)
+		case strings.Contains(run, "-"):
+			b.WriteByte('-')
+		case strings.Contains(run, "_"):
+			b.WriteByte('_')
+		default:
+			b.WriteByte(' ')
+		}
+		i = j
+	}
+	return b.String()
+}
+
+// trimBeforeExtension removes separators before the extension, which is the
+// part of the name from the last dot that is followed by letters and digits
+// only, as in ".mp3".
+func trimBeforeExtension(name string) string {
+	ext := filepath.Ext(name)
+	if len(ext) < 2 || strings.IndexFunc(ext[1:], func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsDigit(c)
+	}) >= 0 {
+		return name
+	}
+	stem := strings.TrimRightFunc(name[:len(name)-len(ext)], isSeparator)
+	if strings.Trim(stem, ".") == "" {
+		// Names must not lose their stem, as in ".mp3" or "_.mp3".
+		return name
+	}
+	return stem + ext
+}
@@ -0,0 +1,53 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSeparatorPolicy(t *testing.T) {
+	p, err := ParseSeparatorPolicy("collapse,mixed, extension,spaces=_")
+	assert.NoError(t, err)
+	assert.Equal(t, SeparatorPolicy{Spaces: '_', Collapse: true, Mixed: true, Extension: true}, p)
+	_, err = ParseSeparatorPolicy("collapse,spaces=+")
+	assert.ErrorContains(t, err, "unknown separator policy 'spaces=+'")
+}
+
+func TestSeparatorRule(t *testing.T) {
+	apply := func(policy SeparatorPolicy, name string) string {
+		return NewSeparatorRule(policy).Apply(name)
+	}
+	name := "My  Song   (Live) .mp3"
+	assert.Equal(t, name, apply(SeparatorPolicy{}, name))
+	assert.Equal(t, "My Song (Live) .mp3", apply(SeparatorPolicy{Collapse: true}, name))
+	assert.Equal(t, "My Song (Live).mp3", apply(SeparatorPolicy{Collapse: true, Extension: true}, name))
+	assert.Equal(t, "My__Song___(Live)_.mp3", apply(SeparatorPolicy{Spaces: '_'}, name))
+	assert.Equal(t, "My_Song_(Live).mp3", apply(SeparatorPolicy{Spaces: '_', Collapse: true, Extension: true}, name))
+	assert.Equal(t, "My-Song-(Live).mp3", apply(SeparatorPolicy{Spaces: '-', Collapse: true, Extension: true}, name))
+	assert.Equal(t, "My.Song.(Live).mp3", apply(SeparatorPolicy{Spaces: '.', Collapse: true, Extension: true}, name))
+
+	assert.Equal(t, "a_b-c d", apply(SeparatorPolicy{Collapse: true}, "a__b--c \t d"))
+	assert.Equal(t, "..hidden.txt", apply(SeparatorPolicy{Collapse: true}, "..hidden..txt"), "keep hidden files hidden")
+
+	mixed := SeparatorPolicy{Mixed: true}
+	assert.Equal(t, "Artist-Title", apply(mixed, "Artist -_- Title"))
+	assert.Equal(t, "Artist-Title", apply(mixed, "Artist - Title"))
+	assert.Equal(t, "a_b", apply(mixed, "a _ b"))
+	assert.Equal(t, "Vol. 2", apply(mixed, "Vol. 2"), "dots are not part of mixed runs")
+	assert.Equal(t, "a__b", apply(mixed, "a__b"), "runs of the same separator are left to Collapse")
+	assert.Equal(t, "My_Song-Live.mp3", apply(SeparatorPolicy{Spaces: '_', Mixed: true}, "My Song - Live.mp3"))
+
+	ext := SeparatorPolicy{Extension: true}
+	assert.Equal(t, "Song.mp3", apply(ext, "Song_ - .mp3"))
+	assert.Equal(t, "Vol. 2", apply(ext, "Vol. 2"), "not an extension")
+	assert.Equal(t, "_.mp3", apply(ext, "_.mp3"), "names must not lose their stem")
+	assert.Equal(t, ".bashrc", apply(ext, ".bashrc"))
+}
+
+func TestSanitizeWithSeparatorRule(t *testing.T) {
+	policy, _ := ParseSeparatorPolicy("collapse,mixed,extension,spaces=_")
+	s := NewSanitizer(DefaultRules()...)
+	assert.NoError(t, s.InsertBefore("trim-dots-and-spaces", NewSeparatorRule(policy)))
+	assert.Equal(t, "Raetsel-Loesung.pdf", s.Sanitize("Rätsel?  –  Lösung .pdf"))
+}
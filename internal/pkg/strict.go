@@ -0,0 +1,89 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StrictASCIIModes lists the modes of strict ASCII: "unicode" writes each rune
+// that is not printable ASCII in the notation of the Unicode standard, e.g.
+// '中' as "U+4E2D", "hex" writes it as "x4e2d", and "fail" refuses to rename
+// names with such runes (see Config.ApplyStrictASCII).
+var StrictASCIIModes = []string{"unicode", "hex", "fail"}
+
+// printableASCIIRune reports whether the rune is a printable ASCII character,
+// from the space (U+0020) to the tilde (U+007E).
+func printableASCIIRune(r rune) bool {
+	return r >= ' ' && r <= '~'
+}
+
+// StrictASCIICharset returns the charset of the printable ASCII characters
+// that the given charset allows.  A nil charset allows all of them.
+func StrictASCIICharset(charset func(rune) bool) func(rune) bool {
+	return bothCharsets(printableASCIIRune, charset)
+}
+
+// NewStrictASCIIRule returns the rule "strict-ascii", which writes every rune
+// that is not printable ASCII, or that the charset does not allow, by its
+// code point (see StrictASCIIModes).  The rule is meant to run last, when
+// the other rules have transliterated whatever they could.
+func NewStrictASCIIRule(mode string, charset func(rune) bool) (Rule, error) {
+	var r strictASCIIRule
+	switch mode {
+	case "unicode":
+		r.format = "U+%04X"
+	case "hex":
+		r.format = "x%04x"
+	default:
+		return nil, fmt.Errorf("unknown strict ASCII mode '%s' (must be one of: unicode, hex)", mode)
+	}
+	r.allowed = StrictASCIICharset(charset)
+	for _, c := range fmt.Sprintf(r.format, 0xABCDEF) + "0123456789" {
+		if !r.allowed(c) {
+			return nil, fmt.Errorf("strict ASCII mode '%s' writes '%c', which the target system does not allow", mode, c)
+		}
+	}
+	return r, nil
+}
+
+type strictASCIIRule struct {
+	format  string
+	allowed func(rune) bool
+}
+
+func (r strictASCIIRule) Name() string { return "strict-ascii" }
+
+func (r strictASCIIRule) Apply(name string) string {
+	var b strings.Builder
+	for _, c := range name {
+		if r.allowed(c) {
+			b.WriteRune(c)
+		} else {
+			_, _ = fmt.Fprintf(&b, r.format, c)
+		}
+	}
+	return b.String()
+}
+
+func (r strictASCIIRule) Explain(before, after string) string {
+	var encoded []string
+	seen := map[rune]bool{}
+	for _, c := range before {
+		if !r.allowed(c) && !seen[c] {
+			seen[c] = true
+			encoded = append(encoded, fmt.Sprintf("%q as %s", c, fmt.Sprintf(r.format, c)))
+		}
+	}
+	return "wrote " + strings.Join(encoded, ", ") + ", which no rule transliterated to ASCII"
+}
+
+// checkCharset returns an error if the name contains a rune that the charset
+// does not allow.
+func checkCharset(name string, charset func(rune) bool) error {
+	for _, c := range name {
+		if !charset(c) {
+			return fmt.Errorf("sanitized name '%s' contains %q (U+%04X), which is not allowed in strict ASCII mode", name, c, c)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStrictASCIIRule(t *testing.T) {
+	unicodeRule, err := NewStrictASCIIRule("unicode", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "U+4E2DU+6587.txt", unicodeRule.Apply("中文.txt"))
+	assert.Equal(t, "aU+0007bU+1F600", unicodeRule.Apply("a\x07b😀"))
+
+	hexRule, err := NewStrictASCIIRule("hex", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "x4e2dx6587.txt", hexRule.Apply("中文.txt"))
+	assert.Equal(t, "Kafka ~ (1915).pdf", hexRule.Apply("Kafka ~ (1915).pdf"), "keep printable ASCII")
+
+	macosRule, err := NewStrictASCIIRule("hex", macosRune)
+	assert.NoError(t, err)
+	assert.Equal(t, "ax003ab", macosRule.Apply("a:b"), "encode ASCII that the charset does not allow")
+
+	_, err = NewStrictASCIIRule("unicode", isPortable)
+	assert.ErrorContains(t, err, "writes '+'")
+	_, err = NewStrictASCIIRule("hex", isPortable)
+	assert.NoError(t, err)
+	_, err = NewStrictASCIIRule("octal", nil)
+	assert.ErrorContains(t, err, "unknown strict ASCII mode 'octal'")
+}
+
+func TestStrictASCIIRuleExplains(t *testing.T) {
+	rule, _ := NewStrictASCIIRule("unicode", nil)
+	assert.Equal(t, `wrote '中' as U+4E2D, '文' as U+6587, which no rule transliterated to ASCII`,
+		rule.(Explainer).Explain("中文中", rule.Apply("中文中")))
+}
+
+func TestApplyStrictASCII(t *testing.T) {
+	config := Config{}
+	assert.ErrorContains(t, config.ApplyStrictASCII("octal", Profile{}), "must be one of: unicode, hex, fail")
+	assert.Nil(t, config.Charset)
+
+	assert.NoError(t, config.ApplyStrictASCII("hex", Profile{}))
+	rules := config.Sanitizer.Rules()
+	assert.Equal(t, "strict-ascii", rules[len(rules)-1].Name())
+	assert.Equal(t, "Tokyo x6771x4eac.jpg", config.Sanitizer.Sanitize("Tōkyō 東京.jpg"))
+	assert.True(t, config.Charset('~'))
+	assert.False(t, config.Charset('é'))
+
+	posix, _ := LookupProfile("posix")
+	config = Config{}
+	assert.ErrorContains(t, config.ApplyStrictASCII("unicode", posix), "writes '+'")
+	assert.NoError(t, config.ApplyStrictASCII("fail", posix))
+	assert.Nil(t, config.Sanitizer, "mode 'fail' adds no rule")
+	assert.False(t, config.Charset(' '))
+}
+
+func TestRenameInStrictASCIIMode(t *testing.T) {
+	newRoot := func() FsNode {
+		root := FsNode{name: "share", originalPath: "share", isDir: true}
+		root.AddNestedChild("share/中.txt", false)
+		root.AddNestedChild("share/U+4E2D.txt", false)
+		return root
+	}
+	config := Config{
+		MaxRenameAttemptsPerPath: 10,
+		MaxBasenameLength:        255,
+		SilentMode:               true,
+	}
+	assert.NoError(t, config.ApplyStrictASCII("unicode", Profile{}))
+	root := newRoot()
+	assert.NoError(t, Rename(false, &root, config))
+	assert.ElementsMatch(t, []string{"share", "share/U+4E2D_1.txt", "share/U+4E2D.txt"}, root.Paths())
+
+	config.Sanitizer = nil
+	assert.NoError(t, config.ApplyStrictASCII("fail", Profile{}))
+	root = newRoot()
+	assert.ErrorContains(t, Rename(false, &root, config),
+		`failed to rename 'share/中.txt', because sanitized name '中.txt' contains '中' (U+4E2D)`)
+	root = FsNode{name: "share", originalPath: "share", isDir: true}
+	root.AddNestedChild("share/\xe9\x1b中.txt", false)
+	assert.ErrorContains(t, Rename(false, &root, config), `failed to rename 'share/\xe9\x1b中.txt', because`,
+		"undecodable bytes and control characters are escaped")
+
+	han, _ := NewAllowlist("", nil, []string{"Han"})
+	config.Sanitizer = NewSanitizer(DefaultRules()...)
+	config.Sanitizer.SetAllowlist(han)
+	root = newRoot()
+	assert.NoError(t, Rename(false, &root, config), "kept characters are allowed")
+}
@@ -0,0 +1,38 @@
+package internal
+
+// symbols maps currency, legal, and math symbols to text.  Currencies are
+// written as their ISO 4217 codes, unless the locale knows better (see
+// Locale).
+var symbols = map[rune]string{
+	// currencies
+	'€': "EUR", '£': "GBP", '¥': "JPY", '¢': "ct", '₹': "INR", '₽': "RUB",
+	'₩': "KRW", '₺': "TRY", '₪': "ILS", '₫': "VND", '฿': "THB", '₴': "UAH",
+	'₱': "PHP", '₦': "NGN", '₿': "BTC",
+	// legal
+	'©': "(c)", '®': "(R)", '™': "TM", '℗': "(P)",
+	// math and units
+	'°': "deg", '×': "x", '÷': "div", '±': "+-", '‰': "permille",
+	'∞': "inf", '√': "sqrt", '≈': "~", '℃': "degC", '℉': "degF",
+	'½': "1-2", '⅓': "1-3", '⅔': "2-3", '¼': "1-4", '¾': "3-4", '⅕': "1-5",
+	'⅙': "1-6", '⅛': "1-8", '⅜': "3-8", '⅝': "5-8", '⅞': "7-8",
+}
+
+// NewSymbolRule returns the rule "replace-symbols" for the given locale (see
+// Locales), which replaces currency, legal, and math symbols with text, e.g.
+// '€' with "EUR", or with "Euro" for German.
+func NewSymbolRule(locale string) (Rule, error) {
+	l, err := LookupLocale(locale)
+	if err != nil {
+		return nil, err
+	}
+	var oldnew []string
+	for symbol, text := range symbols {
+		if localized, ok := l.symbols[symbol]; ok {
+			text = localized
+		}
+		oldnew = append(oldnew, string(symbol), text)
+	}
+	return NewReplaceRule("replace-symbols", oldnew...), nil
+}
+
+var replaceSymbols, _ = NewSymbolRule("de")
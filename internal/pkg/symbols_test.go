@@ -0,0 +1,38 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSymbolRule(t *testing.T) {
+	german, err := NewSymbolRule("de")
+	require.NoError(t, err)
+	assert.Equal(t, "Rechnung 49Euro.pdf", german.Apply("Rechnung 49€.pdf"))
+	assert.Equal(t, "(c) 2024 ACME(R) Widget TM", german.Apply("© 2024 ACME® Widget ™"))
+	assert.Equal(t, "90deg 20x30 1-2 3-4", german.Apply("90° 20×30 ½ ¾"))
+	assert.Equal(t, "20degC", german.Apply("20℃"))
+
+	swedish, err := NewSymbolRule("sv")
+	require.NoError(t, err)
+	assert.Equal(t, "Rechnung 49EUR, 12GBP.pdf", swedish.Apply("Rechnung 49€, 12£.pdf"))
+
+	turkish, err := NewSymbolRule("tr")
+	require.NoError(t, err)
+	assert.Equal(t, "Fatura 100TL.pdf", turkish.Apply("Fatura 100₺.pdf"))
+}
+
+func TestSanitizeReplacesSymbols(t *testing.T) {
+	assert.Equal(t, "Scan 2024 - 49,90Euro (c) ACME.pdf", Sanitize("Scan 2024 – 49,90€ © ACME.pdf"))
+	assert.Equal(t, "Rezept 1-2 Tasse, 180deg.txt", Sanitize("Rezept ½ Tasse, 180°.txt"))
+}
+
+func TestRulesFileOverridesSymbols(t *testing.T) {
+	f := &RulesFile{Strings: []Mapping{{From: "€", To: "EUR"}}}
+	rules, err := f.Combine(DefaultRules())
+	assert.NoError(t, err)
+	s := NewSanitizer(rules...)
+	assert.Equal(t, "49EUR.pdf", s.Sanitize("49€.pdf"))
+}
@@ -0,0 +1,226 @@
+package internal
+
+import "strings"
+
+// thaiConsonant is the romanization of a Thai consonant at the beginning and
+// at the end of a syllable.  Consonants with an empty final do not end
+// syllables.
+type thaiConsonant struct {
+	initial, final string
+}
+
+// thaiConsonants maps the Thai consonants according to the Royal Thai General
+// System of Transcription (RTGS).
+var thaiConsonants = map[rune]thaiConsonant{
+	'ก': {"k", "k"}, 'ข': {"kh", "k"}, 'ฃ': {"kh", "k"}, 'ค': {"kh", "k"},
+	'ฅ': {"kh", "k"}, 'ฆ': {"kh", "k"}, 'ง': {"ng", "ng"}, 'จ': {"ch", "t"},
+	'ฉ': {"ch", ""}, 'ช': {"ch", "t"}, 'ซ': {"s", "t"}, 'ฌ': {"ch", ""},
+	'ญ': {"y", "n"}, 'ฎ': {"d", "t"}, 'ฏ': {"t", "t"}, 'ฐ': {"th", "t"},
+	'ฑ': {"th", "t"}, 'ฒ': {"th", "t"}, 'ณ': {"n", "n"}, 'ด': {"d", "t"},
+	'ต': {"t", "t"}, 'ถ': {"th", "t"}, 'ท': {"th", "t"}, 'ธ': {"th", "t"},
+	'น': {"n", "n"}, 'บ': {"b", "p"}, 'ป': {"p", "p"}, 'ผ': {"ph", ""},
+	'ฝ': {"f", ""}, 'พ': {"ph", "p"}, 'ฟ': {"f", "p"}, 'ภ': {"ph", "p"},
+	'ม': {"m", "m"}, 'ย': {"y", "i"}, 'ร': {"r", "n"}, 'ล': {"l", "n"},
+	'ว': {"w", "o"}, 'ศ': {"s", "t"}, 'ษ': {"s", "t"}, 'ส': {"s", "t"},
+	'ห': {"h", ""}, 'ฬ': {"l", "n"}, 'อ': {"", ""}, 'ฮ': {"h", ""},
+}
+
+// thaiVowels maps the vowel signs that follow a consonant, or are written
+// above or below it, by the longest match first.  The bool is true if the
+// vowel can be followed by a final consonant.
+var thaiVowels = []struct {
+	signs  string
+	latin  string
+	closed bool
+}{
+	{"ัว", "ua", true}, {"ือ", "ue", true}, {"ั", "a", true}, {"ะ", "a", false},
+	{"า", "a", true}, {"ำ", "am", false}, {"ิ", "i", true}, {"ี", "i", true},
+	{"ึ", "ue", true}, {"ื", "ue", true}, {"ุ", "u", true}, {"ู", "u", true},
+	{"อ", "o", true},
+}
+
+// thaiLeadingVowels maps the vowels that are written before the consonant,
+// together with the signs that follow it, by the longest match first.
+var thaiLeadingVowels = map[rune][]struct {
+	signs  string
+	latin  string
+	closed bool
+}{
+	'เ': {
+		{"ีย", "ia", true}, {"ือ", "uea", true}, {"าะ", "o", false}, {"า", "ao", false},
+		{"อะ", "oe", false}, {"อ", "oe", true}, {"ิ", "oe", true}, {"ย", "oei", false},
+		{"ะ", "e", false}, {"", "e", true},
+	},
+	'แ': {{"ะ", "ae", false}, {"", "ae", true}},
+	'โ': {{"ะ", "o", false}, {"", "o", true}},
+	'ใ': {{"ย", "ai", false}, {"", "ai", false}},
+	'ไ': {{"ย", "ai", false}, {"", "ai", false}},
+}
+
+// transliterateThai romanizes a run of Thai according to the RTGS, as in
+// "กรุงเทพ" ("krungthep").  Since Thai is written without spaces between
+// words, syllables are found with a simple parser that knows the common
+// spellings, but not the exceptions of loanwords.
+func transliterateThai(run []rune) string {
+	runes := stripThaiSigns(run)
+	var b strings.Builder
+	var syllable string
+	for i := 0; i < len(runes); {
+		start := b.Len()
+		n := parseThaiSyllable(&b, runes[i:])
+		if n == 0 {
+			switch c := runes[i]; {
+			case c == 'ๆ': // mai yamok repeats the previous syllable
+				b.WriteString(syllable)
+			case c == 'ฯ': // paiyannoi abbreviates, and is dropped
+			default:
+				if latin, ok := transliterateDigit(c); ok {
+					b.WriteString(latin)
+				} else {
+					b.WriteRune(c)
+				}
+			}
+			i++
+			continue
+		}
+		syllable = b.String()[start:]
+		i += n
+	}
+	return b.String()
+}
+
+// stripThaiSigns removes the tone marks, the sign mai taikhu, and the
+// consonants that are silenced by a thanthakhat, which do not show in the
+// RTGS.
+func stripThaiSigns(run []rune) []rune {
+	var runes []rune
+	for _, c := range run {
+		switch c {
+		case '่', '้', '๊', '๋', '็':
+		case '์':
+			// The thanthakhat silences the consonant before it,
+			// together with its vowel, if any.
+			for len(runes) > 0 {
+				last := runes[len(runes)-1]
+				runes = runes[:len(runes)-1]
+				if _, ok := thaiConsonants[last]; ok {
+					break
+				}
+			}
+		default:
+			runes = append(runes, c)
+		}
+	}
+	return runes
+}
+
+// parseThaiSyllable writes the romanization of the syllable at the start of
+// runes, and returns the number of runes of the syllable, or 0 if runes does
+// not start with a syllable.
+func parseThaiSyllable(b *strings.Builder, runes []rune) int {
+	i := 0
+	var lead rune
+	if _, ok := thaiLeadingVowels[runes[0]]; ok {
+		lead = runes[0]
+		i++
+	}
+	if i >= len(runes) {
+		return 0
+	}
+	first, ok := thaiConsonants[runes[i]]
+	if !ok {
+		return 0
+	}
+
+	// the initial consonant or cluster
+	switch {
+	case i+1 < len(runes) && runes[i] == 'ห' && strings.ContainsRune("งญนมยรลว", runes[i+1]):
+		// The leading 'ห' is silent, and only changes the tone.
+		b.WriteString(thaiConsonants[runes[i+1]].initial)
+		i += 2
+	case i+1 < len(runes) && isThaiCluster(runes[i], runes[i+1]) && (lead != 0 || startsThaiVowel(runes, i+2)):
+		b.WriteString(first.initial + thaiConsonants[runes[i+1]].initial)
+		i += 2
+	default:
+		b.WriteString(first.initial)
+		i++
+	}
+
+	// the vowel
+	vowel, closed := "", true
+	if lead != 0 {
+		for _, v := range thaiLeadingVowels[lead] {
+			if strings.HasPrefix(string(runes[i:]), v.signs) {
+				vowel, closed = v.latin, v.closed
+				i += len([]rune(v.signs))
+				break
+			}
+		}
+	} else {
+		for _, v := range thaiVowels {
+			if strings.HasPrefix(string(runes[i:]), v.signs) {
+				vowel, closed = v.latin, v.closed
+				i += len([]rune(v.signs))
+				break
+			}
+		}
+	}
+	if vowel == "" {
+		// Without a written vowel, 'ว' between consonants is "ua", as in
+		// "สวน", and the inherent vowel is "o" in closed syllables, as in
+		// "คน", and "a" in open ones.
+		switch {
+		case i+1 < len(runes) && runes[i] == 'ว' && isThaiFinal(runes, i+1):
+			vowel = "ua"
+			i++
+		case isThaiFinal(runes, i):
+			vowel = "o"
+		default:
+			vowel, closed = "a", false
+		}
+	}
+	b.WriteString(vowel)
+
+	// the final consonant
+	if closed && isThaiFinal(runes, i) {
+		b.WriteString(thaiConsonants[runes[i]].final)
+		i++
+	}
+	return i
+}
+
+// isThaiCluster reports whether the consonants form an initial cluster, like
+// "kr" or "pl".
+func isThaiCluster(first, second rune) bool {
+	switch second {
+	case 'ร', 'ล':
+		return strings.ContainsRune("กขคตปพผ", first)
+	case 'ว':
+		return strings.ContainsRune("กขค", first)
+	}
+	return false
+}
+
+// startsThaiVowel reports whether runes[i] is a vowel sign that follows a
+// consonant, or is written above or below it.
+func startsThaiVowel(runes []rune, i int) bool {
+	if i >= len(runes) {
+		return false
+	}
+	for _, v := range thaiVowels {
+		if strings.HasPrefix(string(runes[i:]), v.signs) {
+			return true
+		}
+	}
+	return false
+}
+
+// isThaiFinal reports whether runes[i] is a consonant that ends the current
+// syllable rather than begins the next one.
+func isThaiFinal(runes []rune, i int) bool {
+	if i >= len(runes) {
+		return false
+	}
+	c, ok := thaiConsonants[runes[i]]
+	return ok && c.final != "" && !startsThaiVowel(runes, i+1)
+}
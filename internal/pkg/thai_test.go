@@ -0,0 +1,31 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransliterateThai(t *testing.T) {
+	examples := map[string]string{
+		"สวัสดี":    "sawatdi",
+		"กรุงเทพ":   "krungthep",
+		"ขอบคุณ":    "khopkhun",
+		"ภาษาไทย":   "phasathai",
+		"ประเทศไทย": "prathetthai",
+		"เชียงใหม่": "chiangmai",
+		"ภูเก็ต":    "phuket",
+		"คน":        "khon",
+		"น้ำ":       "nam",
+		"แมว":       "maeo",
+		"สวน":       "suan",
+		"กว่า":      "kwa",
+		"เพลง":      "phleng",
+		"ใคร":       "khrai",
+		"เด็กๆ":     "dekdek",
+		"๒๕๖๗":      "2567",
+	}
+	for thai, latin := range examples {
+		assert.Equal(t, latin, transliterateThai([]rune(thai)), thai)
+	}
+}
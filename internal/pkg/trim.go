@@ -0,0 +1,59 @@
+package internal
+
+import (
+	"strings"
+	"unicode"
+)
+
+type trimRule struct {
+	replacement string
+}
+
+// NewTrimRule returns a rule for leading whitespace and for trailing
+// whitespace and dots, which SMB clients silently strip or refuse.  If the
+// replacement is empty, the rule removes these characters, otherwise it
+// replaces each run of them with the replacement.  Leading dots are kept, so
+// that hidden files like ".bashrc" remain hidden.  Names that consist of dots
+// and spaces only become "_", except for the special names "." and "..".
+func NewTrimRule(replacement string) Rule {
+	return trimRule{replacement: replacement}
+}
+
+func (r trimRule) Name() string { return "trim-dots-and-spaces" }
+
+func (r trimRule) Apply(name string) string {
+	if name == "." || name == ".." {
+		return name
+	}
+	trimmed := strings.TrimLeftFunc(name, unicode.IsSpace)
+	if trimmed != name {
+		trimmed = r.replacement + trimmed
+	}
+	// The leading dots of hidden files must not be mistaken for trailing dots.
+	body := strings.TrimLeft(trimmed, ".")
+	dots := trimmed[:len(trimmed)-len(body)]
+	trimmedBody := strings.TrimRightFunc(body, isDotOrSpace)
+	if trimmedBody != body {
+		trimmedBody += r.replacement
+	}
+	trimmed = dots + trimmedBody
+	if name != "" && strings.Trim(trimmed, ".") == "" {
+		// Names must not become empty, nor consist of dots only, like "..."
+		// or the ellipsis "…" after normalization, which SMB clients refuse,
+		// too.  Such names carry no meaning that is worth keeping.
+		return "_"
+	}
+	return trimmed
+}
+
+func (r trimRule) Explain(before, after string) string {
+	verb := "trimmed"
+	if r.replacement != "" {
+		verb = "replaced"
+	}
+	return verb + " leading spaces or trailing dots and spaces, which SMB clients strip or refuse"
+}
+
+func isDotOrSpace(r rune) bool {
+	return r == '.' || unicode.IsSpace(r)
+}
@@ -0,0 +1,45 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrimRule(t *testing.T) {
+	trim := NewTrimRule("")
+	assert.Equal(t, "foo", trim.Apply("foo."))
+	assert.Equal(t, "foo", trim.Apply("foo. . "))
+	assert.Equal(t, "foo", trim.Apply("  foo"))
+	assert.Equal(t, "foo.mp3", trim.Apply(" foo.mp3. "))
+	assert.Equal(t, "foo .mp3", trim.Apply("foo .mp3"), "keep extensions intact")
+	assert.Equal(t, "foo.tar.gz", trim.Apply("foo.tar.gz"), "keep extensions intact")
+	assert.Equal(t, ".bashrc", trim.Apply(".bashrc"), "keep hidden files hidden")
+	assert.Equal(t, "..foo", trim.Apply("..foo.."), "keep hidden files hidden")
+	assert.Equal(t, ".bashrc", trim.Apply(" .bashrc"), "keep hidden files hidden")
+	assert.Equal(t, "_", trim.Apply("   "), "names must not become empty")
+	assert.Equal(t, "_", trim.Apply("..."), "names must not consist of dots only")
+	assert.Equal(t, "_", trim.Apply(". ."), "names must not consist of dots only")
+	assert.Equal(t, "", trim.Apply(""))
+	assert.Equal(t, ".", trim.Apply("."), "keep special names")
+	assert.Equal(t, "..", trim.Apply(".."), "keep special names")
+}
+
+func TestTrimRuleWithReplacement(t *testing.T) {
+	replace := NewTrimRule("_")
+	assert.Equal(t, "foo_", replace.Apply("foo."))
+	assert.Equal(t, "foo_", replace.Apply("foo. . "))
+	assert.Equal(t, "_foo", replace.Apply("  foo"))
+	assert.Equal(t, "_foo.mp3_", replace.Apply(" foo.mp3. "))
+	assert.Equal(t, ".bashrc", replace.Apply(".bashrc"))
+	assert.Equal(t, "_", replace.Apply("   "))
+	assert.Equal(t, "_", replace.Apply("..."))
+}
+
+func TestTrimRuleInDefaultRules(t *testing.T) {
+	assert.Equal(t, "Rueckblick", Sanitize(" Rückblick. "))
+	assert.Equal(t, "NUL_", Sanitize("NUL. "), "trim before rewriting reserved names")
+	notes := defaultSanitizer.Notes("foo.")
+	assert.Len(t, notes, 1)
+	assert.Contains(t, notes[0], "trimmed")
+}
@@ -0,0 +1,16 @@
+package internal
+
+import "regexp"
+
+// replaceWindowsForbiddenChars replaces the characters that Windows does not
+// allow in the names of files and directories.
+var replaceWindowsForbiddenChars = NewForbiddenCharsRule("replace-windows-forbidden-chars", `<>:"/\|?*`, '_')
+
+// windowsReservedName matches the names of DOS devices, which Windows
+// reserves regardless of case and of any extension (e.g., "nul.txt").
+// See https://learn.microsoft.com/en-us/windows/win32/fileio/naming-a-file
+var windowsReservedName = regexp.MustCompile(`(?i)^(CON|PRN|AUX|NUL|COM[0-9¹²³]|LPT[0-9¹²³])( *\..*)?$`)
+
+// rewriteWindowsReservedNames appends an underscore to reserved device names,
+// e.g., "CON.txt" becomes "CON_.txt".
+var rewriteWindowsReservedNames = NewRegexRule("rewrite-windows-reserved-names", windowsReservedName, "${1}_$2")
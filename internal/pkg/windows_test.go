@@ -0,0 +1,30 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRewriteWindowsReservedNames(t *testing.T) {
+	reserved := []string{"CON", "PRN", "AUX", "NUL", "COM1", "COM9", "COM¹", "LPT1", "LPT9", "LPT³"}
+	for _, name := range reserved {
+		assert.Equal(t, name+"_", rewriteWindowsReservedNames.Apply(name))
+		assert.Equal(t, name+"_.txt", rewriteWindowsReservedNames.Apply(name+".txt"))
+	}
+	assert.Equal(t, "con_", rewriteWindowsReservedNames.Apply("con"))
+	assert.Equal(t, "Nul_.txt", rewriteWindowsReservedNames.Apply("Nul.txt"))
+	assert.Equal(t, "com1_.mp3", rewriteWindowsReservedNames.Apply("com1.mp3"))
+	assert.Equal(t, "CON_.tar.gz", rewriteWindowsReservedNames.Apply("CON.tar.gz"))
+	assert.Equal(t, "AUX_ .txt", rewriteWindowsReservedNames.Apply("AUX .txt"))
+
+	notReserved := []string{"CONSOLE", "CON_", "xCON", "NULL.txt", "COM10", "LPT", "CON-1.txt", ".CON"}
+	for _, name := range notReserved {
+		assert.Equal(t, name, rewriteWindowsReservedNames.Apply(name))
+	}
+}
+
+func TestReplaceWindowsForbiddenChars(t *testing.T) {
+	assert.Equal(t, "a_b_c_d_e_f_g_h_i_j", replaceWindowsForbiddenChars.Apply(`a<b>c:d"e/f\g|h?i*j`))
+	assert.Equal(t, "Ähnlich (1).mp3", replaceWindowsForbiddenChars.Apply("Ähnlich (1).mp3"))
+}